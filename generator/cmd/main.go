@@ -5,12 +5,19 @@ import (
 	"fmt"
 	"github.com/NeuronFramework/sql/generator"
 	"io/ioutil"
+	"strings"
 )
 
 func main() {
 	sqlFileFlag := flag.String("sql_file", "", "sql file")
 	ormFileFlag := flag.String("orm_file", "", "orm file")
 	packageNameFlag := flag.String("package_name", "", "package name")
+	softDeleteColumnFlag := flag.String("soft_delete_column", "deleted_at,is_deleted",
+		"soft delete column names, comma separated, empty to disable")
+	rowAliasFlag := flag.Bool("row_alias", false,
+		"use MySQL 8 row alias instead of VALUES() in ON DUPLICATE KEY UPDATE")
+	dbTimestampsFlag := flag.Bool("db_timestamps", false,
+		"leave create_time/update_time with CURRENT_TIMESTAMP defaults to the database")
 	flag.Parse()
 
 	sqlFile := *sqlFileFlag
@@ -34,6 +41,12 @@ func main() {
 	}
 
 	gen := generator.NewGenerator()
+	gen.RowAlias = *rowAliasFlag
+	gen.DbTimestamps = *dbTimestampsFlag
+	gen.SoftDeleteColumnNameList = nil
+	if *softDeleteColumnFlag != "" {
+		gen.SoftDeleteColumnNameList = strings.Split(*softDeleteColumnFlag, ",")
+	}
 	orm, err := gen.Gen(string(sqlData), packageName)
 	if err != nil {
 		fmt.Println(err)
@@ -19,6 +19,81 @@ func (g *Generator) genCommonQuery() {
 	g.Pn("    updateParams []interface{}")
 	g.Pn("    getFields []string")
 	g.Pn("    duplicatedUpdateFields []string")
+	g.Pn("    duplicatedUpdateParams []interface{}")
+	g.Pn("    softDeleteWhere string")
+	g.Pn("    atomic bool")
+	g.Pn("    sharding *wrap.Sharding")
+	g.Pn("    shardKeys []interface{}")
+	g.Pn("    shardKeyUnknown bool") // 条件含OR、NOT时无法由分片键确定分片
+	g.Pn("    allShards bool")
+	g.Pn("}")
+	g.Pn("")
+
+	// 记录分片键条件的值
+	g.Pn("func (q *QueryBase)addShardKey(column string,values ...interface{}) {")
+	g.Pn("    if q.sharding!=nil&&q.sharding.Column==column{")
+	g.Pn("        q.shardKeys=append(q.shardKeys,values...)")
+	g.Pn("    }")
+	g.Pn("}")
+	g.Pn("")
+
+	// 查询所在的分片，未分片时为db
+	g.Pn("func (q *QueryBase)shards(db *wrap.DB) ([]*wrap.DB,error) {")
+	g.Pn("    if q.sharding==nil{")
+	g.Pn("        return []*wrap.DB{db},nil")
+	g.Pn("    }")
+	g.Pn("    if q.allShards{")
+	g.Pn("        return q.sharding.Shards,nil")
+	g.Pn("    }")
+	g.Pn("    if q.shardKeyUnknown{")
+	g.Pn("        return nil,wrap.ErrMissingShardKey")
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    return q.sharding.Route(q.shardKeys)")
+	g.Pn("}")
+	g.Pn("")
+
+	// 写入及返回Row、Rows的查询只在单个分片执行
+	g.Pn("func (q *QueryBase)shard(db *wrap.DB) (*wrap.DB,error) {")
+	g.Pn("    shards,err:=q.shards(db)")
+	g.Pn("    if err!=nil{")
+	g.Pn("        return nil,err")
+	g.Pn("    }")
+	g.Pn("    if len(shards)>1{")
+	g.Pn("        return nil,wrap.ErrCrossShard")
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    return shards[0],nil")
+	g.Pn("}")
+	g.Pn("")
+
+	// 跨分片查询不能在事务中执行，事务只属于一个分片
+	g.Pn("func (q *QueryBase)fanOutShards(ctx context.Context,tx *wrap.Tx,db *wrap.DB) ([]*wrap.DB,error) {")
+	g.Pn("    shards,err:=q.shards(db)")
+	g.Pn("    if err!=nil{")
+	g.Pn("        return nil,err")
+	g.Pn("    }")
+	g.Pn("    if len(shards)>1&&(tx!=nil||wrap.TxFromContext(ctx)!=nil){")
+	g.Pn("        return nil,wrap.ErrCrossShard")
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    return shards,nil")
+	g.Pn("}")
+	g.Pn("")
+
+	// 构造条件，附加软删除过滤
+	g.Pn("func (q *QueryBase)buildWhere() (where string,params []interface{}) {")
+	g.Pn("    where=q.where.String()")
+	g.Pn("    params=append(params,q.whereParams...)")
+	g.Pn("    if q.softDeleteWhere!=\"\"{")
+	g.Pn("        if where==\"\"{")
+	g.Pn("            where=q.softDeleteWhere")
+	g.Pn("        }else{")
+	g.Pn("            where=\"(\"+where+\" ) AND \"+q.softDeleteWhere")
+	g.Pn("        }")
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    return where,params")
 	g.Pn("}")
 	g.Pn("")
 
@@ -26,11 +101,11 @@ func (g *Generator) genCommonQuery() {
 	g.Pn("func (q *QueryBase)buildSelectQuery() (queryString string,params []interface{}) {")
 	g.Pn("    query:=bytes.NewBufferString(\"\")")
 	g.Pn("")
-	g.Pn("    where:=q.where.String()")
+	g.Pn("    where,whereParams:=q.buildWhere()")
 	g.Pn("    if where!=\"\"{")
 	g.Pn("        query.WriteString(\" WHERE \")")
 	g.Pn("        query.WriteString(where)")
-	g.Pn("        params=append(params,q.whereParams...)")
+	g.Pn("        params=append(params,whereParams...)")
 	g.Pn("    }")
 	g.Pn("")
 	g.Pn("    groupByCount:=len(q.groupByFields)")
@@ -87,3 +162,22 @@ func (g *Generator) genCommonQuery() {
 	g.Pn("}")
 	g.Pn("")
 }
+
+// 标记语句所属的表，出错时记录在wrap.Error中；启用追踪时以Dao.Method命名span，err为方法结束时的错误表达式
+func (g *Generator) genQueryContext(t *Table, method string, err string) {
+	g.Pn("    ctx=wrap.WithTable(ctx,q.tableName)")
+	g.Pn("    ctx,span:=q.dao.db.StartSpan(ctx,\"%sDao.%s\")", t.GoName, method)
+	g.Pn("    defer func(){")
+	g.Pn("        span.End(%s)", err)
+	g.Pn("    }()")
+	g.Pn("")
+}
+
+// 选择执行语句的分片db，ret为出错时的返回语句
+func (g *Generator) genQueryShard(shard string, ret string) {
+	g.Pn("    db,err:=%s", shard)
+	g.Pn("    if err!=nil{")
+	g.Pn("        %s", ret)
+	g.Pn("    }")
+	g.Pn("")
+}
@@ -7,15 +7,14 @@ func (g *Generator) genDao(t *Table) {
 
 	//定义
 	g.Pn("type %sDao struct{", t.GoName)
-	g.Pn("    logger *zap.Logger")
 	g.Pn("    db *DB")
+	g.Pn("    sharding *wrap.Sharding")
 	g.Pn("}")
 	g.Pn("")
 
 	//构造函数
 	g.Pn("func New%sDao(db *DB)(t *%sDao,err error){", t.GoName, t.GoName)
 	g.Pn("    t=&%sDao{}", t.GoName)
-	g.Pn("    t.logger=log.TypedLogger(t)")
 	g.Pn("    t.db=db")
 	g.Pn("    ")
 	g.Pn("    return t,nil")
@@ -28,7 +27,64 @@ func (g *Generator) genDao(t *Table) {
 	g.Pn("    q.dao=dao")
 	g.Pn("    q.tableName=\"%s\"", t.DbName)
 	g.Pn("    q.where=bytes.NewBufferString(\"\")")
+	if t.SoftDeleteColumn != nil {
+		g.Pn("    q.softDeleteWhere=\"%s\"", t.SoftDeleteAliveCondition())
+	}
+	g.Pn("    q.sharding=dao.sharding")
 	g.Pn("    return q")
 	g.Pn("}")
 	g.Pn("")
+
+	g.genDaoSharding(t)
+}
+
+func (g *Generator) genDaoSharding(t *Table) {
+	//分片，为nil时使用DB
+	g.Pn("func (dao *%sDao)SetSharding(s *wrap.Sharding) error {", t.GoName)
+	g.Pn("    if s!=nil{")
+	g.Pn("        if _,ok:=dao.fieldValue(&%s{},s.Column);!ok{", t.GoName)
+	g.Pn("            return fmt.Errorf(\"%s sharding unknown column %%s\",s.Column)", t.DbName)
+	g.Pn("        }")
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    dao.sharding=s")
+	g.Pn("    return nil")
+	g.Pn("}")
+	g.Pn("")
+
+	//按列名取值
+	g.Pn("func (dao *%sDao)fieldValue(e *%s,field string)(v interface{},ok bool){", t.GoName, t.GoName)
+	g.Pn("    switch field{")
+	for _, c := range t.ColumnList {
+		g.Pn("    case \"%s\":", c.DbName)
+		g.Pn("        return e.%s,true", c.GoName)
+	}
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    return nil,false")
+	g.Pn("}")
+	g.Pn("")
+
+	//实体所在的分片，批量写入的实体须在同一分片
+	g.Pn("func (dao *%sDao)entityShard(list ...*%s)(*wrap.DB,error){", t.GoName, t.GoName)
+	g.Pn("    if dao.sharding==nil||len(list)==0{")
+	g.Pn("        return dao.db.DB,nil")
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    var db *wrap.DB")
+	g.Pn("    for _,e:=range list{")
+	g.Pn("        v,_:=dao.fieldValue(e,dao.sharding.Column)")
+	g.Pn("        shard,err:=dao.sharding.Shard(v)")
+	g.Pn("        if err!=nil{")
+	g.Pn("            return nil,err")
+	g.Pn("        }")
+	g.Pn("        if db!=nil&&shard!=db{")
+	g.Pn("            return nil,wrap.ErrCrossShard")
+	g.Pn("        }")
+	g.Pn("        db=shard")
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    return db,nil")
+	g.Pn("}")
+	g.Pn("")
 }
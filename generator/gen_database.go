@@ -3,7 +3,7 @@ package generator
 func (g *Generator) genDatabase() {
 	//def
 	g.Pn("type DB struct{")
-	g.Pn("    wrap.DB")
+	g.Pn("    *wrap.DB")
 	for _, v := range g.TableList {
 		g.Pn("    %s *%sDao", v.GoName, v.GoName)
 	}
@@ -11,7 +11,7 @@ func (g *Generator) genDatabase() {
 	g.Pn("")
 
 	//new
-	g.Pn("func NewDB() (d *DB, err error) {")
+	g.Pn("func NewDB(opts ...wrap.Option) (d *DB, err error) {")
 	g.Pn("    d = &DB{}")
 	g.Pn("")
 	g.Pn("    connectionString := os.Getenv(\"DB\")")
@@ -19,11 +19,11 @@ func (g *Generator) genDatabase() {
 	g.Pn("	      return nil, fmt.Errorf(\"DB env nil\")")
 	g.Pn("    }")
 	g.Pn("    connectionString+=\"/%s?parseTime=true\"", g.DbName)
-	g.Pn("db, err := wrap.Open(\"mysql\", connectionString)")
+	g.Pn("db, err := wrap.Open(\"mysql\", connectionString,opts...)")
 	g.Pn("if err != nil {")
 	g.Pn("	return nil, err")
 	g.Pn("}")
-	g.Pn("d.DB=*db")
+	g.Pn("d.DB=db")
 	g.Pn("")
 	g.Pn("err = d.Ping(context.Background())")
 	g.Pn("if err != nil {")
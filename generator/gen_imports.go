@@ -6,11 +6,11 @@ func (g *Generator) genImports() {
 	g.Pn("package %s", strings.Replace(g.Namespace, "-", "_", -1))
 	g.Pn("")
 	g.Pn("import(")
-	g.Pn("    \"go.uber.org/zap\"")
-	g.Pn("    \"github.com/NeuronFramework/log\"")
 	g.Pn("    \"bytes\"")
+	g.Pn("    \"errors\"")
 	g.Pn("    \"fmt\"")
 	g.Pn("    \"os\"")
+	g.Pn("    \"sort\"")
 	g.Pn("    \"time\"")
 	g.Pn("    \"strings\"")
 	g.Pn("    \"context\"")
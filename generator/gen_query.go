@@ -5,6 +5,9 @@ func (g *Generator) genQuery(t *Table) {
 	g.Pn("type %sQuery struct {", t.GoName)
 	g.Pn("    QueryBase")
 	g.Pn("    dao *%sDao", t.GoName)
+	if t.SoftDeleteColumn != nil {
+		g.Pn("    hardDelete bool")
+	}
 	g.Pn("}")
 	g.Pn("")
 
@@ -32,6 +35,7 @@ func (g *Generator) genQuery(t *Table) {
 	// 或
 	g.Pn("func(q *%sQuery)Or() *%sQuery {", t.GoName, t.GoName)
 	g.Pn("    q.where.WriteString(\" OR\")")
+	g.Pn("    q.shardKeyUnknown=true")
 	g.Pn("    return q")
 	g.Pn("}")
 	g.Pn("")
@@ -39,6 +43,7 @@ func (g *Generator) genQuery(t *Table) {
 	// 非
 	g.Pn("func(q *%sQuery)Not() *%sQuery {", t.GoName, t.GoName)
 	g.Pn("    q.where.WriteString(\" NOT\")")
+	g.Pn("    q.shardKeyUnknown=true")
 	g.Pn("    return q")
 	g.Pn("}")
 	g.Pn("")
@@ -49,6 +54,7 @@ func (g *Generator) genQuery(t *Table) {
 		g.Pn("func (q *%sQuery)%sEqual(v %s) *%sQuery {", t.GoName, c.GoName, c.GoTypeReal, t.GoName)
 		g.Pn("    q.where.WriteString(\" %s=?\")", c.DbName)
 		g.Pn("    q.whereParams=append(q.whereParams,v)")
+		g.Pn("    q.addShardKey(\"%s\",v)", c.DbName)
 		g.Pn("    return q")
 		g.Pn("}")
 		g.Pn("")
@@ -117,7 +123,10 @@ func (g *Generator) genQuery(t *Table) {
 			g.Pn("    q.where.WriteString(\" %s IN(\")", c.DbName)
 			g.Pn("    q.where.WriteString(wrap.RepeatWithSeparator(\"?\",len(items),\",\"))")
 			g.Pn("    q.where.WriteString(\")\")")
-			g.Pn("    q.whereParams=append(q.whereParams,items)")
+			g.Pn("    for _,v:=range items{")
+			g.Pn("        q.whereParams=append(q.whereParams,v)")
+			g.Pn("        q.addShardKey(\"%s\",v)", c.DbName)
+			g.Pn("    }")
 			g.Pn("    return q")
 			g.Pn("}")
 			g.Pn("")
@@ -126,8 +135,7 @@ func (g *Generator) genQuery(t *Table) {
 
 	//分组
 	for _, c := range t.ColumnList {
-		if c.AutoIncrement || c.DbName == "create_time" ||
-			c.DbName == "update_time" || c.DbName == "update_version" ||
+		if c.AutoIncrement || t.IsTimestampColumn(c) || c.DbName == "update_version" ||
 			c.IsUniqueIndex(t) {
 			continue
 		}
@@ -171,6 +179,13 @@ func (g *Generator) genQuery(t *Table) {
 	g.Pn("}")
 	g.Pn("")
 
+	//未指定分片键时查询所有分片
+	g.Pn("func (q *%sQuery)AllShards() *%sQuery {", t.GoName, t.GoName)
+	g.Pn("    q.allShards=true")
+	g.Pn("    return q")
+	g.Pn("}")
+	g.Pn("")
+
 	//写锁
 	g.Pn("func (q *%sQuery)ForUpdate() *%sQuery {", t.GoName, t.GoName)
 	g.Pn("    q.forUpdate=true")
@@ -185,9 +200,40 @@ func (g *Generator) genQuery(t *Table) {
 	g.Pn("}")
 	g.Pn("")
 
+	//批量写入分批执行时使用同一事务
+	g.Pn("func (q *%sQuery)Atomic() *%sQuery {", t.GoName, t.GoName)
+	g.Pn("    q.atomic=true")
+	g.Pn("    return q")
+	g.Pn("}")
+	g.Pn("")
+
+	//软删除
+	if t.SoftDeleteColumn != nil {
+		// 包含已删除记录
+		g.Pn("func (q *%sQuery)WithDeleted() *%sQuery {", t.GoName, t.GoName)
+		g.Pn("    q.softDeleteWhere=\"\"")
+		g.Pn("    return q")
+		g.Pn("}")
+		g.Pn("")
+
+		// 仅已删除记录
+		g.Pn("func (q *%sQuery)OnlyDeleted() *%sQuery {", t.GoName, t.GoName)
+		g.Pn("    q.softDeleteWhere=\"%s\"", t.SoftDeleteDeletedCondition())
+		g.Pn("    return q")
+		g.Pn("}")
+		g.Pn("")
+
+		// 物理删除
+		g.Pn("func (q *%sQuery)HardDelete() *%sQuery {", t.GoName, t.GoName)
+		g.Pn("    q.hardDelete=true")
+		g.Pn("    return q")
+		g.Pn("}")
+		g.Pn("")
+	}
+
 	//更新字段
 	for _, c := range t.ColumnList {
-		if c.AutoIncrement || c.DbName == "create_time" || c.DbName == "update_time" {
+		if c.AutoIncrement || t.IsTimestampColumn(c) {
 			continue
 		}
 
@@ -201,7 +247,7 @@ func (g *Generator) genQuery(t *Table) {
 
 	//重复时更新字段
 	for _, c := range t.ColumnList {
-		if c.AutoIncrement || c.DbName == "create_time" || c.DbName == "update_time" {
+		if c.AutoIncrement || t.IsTimestampColumn(c) {
 			continue
 		}
 
@@ -235,8 +281,35 @@ func (g *Generator) genQuery(t *Table) {
 			continue
 		}
 
+		// 使用插入值
 		g.Pn("func (q *%sQuery)DuplicatedUpdate%s()*%sQuery{", t.GoName, c.GoName, t.GoName)
-		g.Pn("    q.duplicatedUpdateFields=append(q.duplicatedUpdateFields,\"%s=VALUES(%s)\")", c.DbName, c.DbName)
+		g.Pn("    q.duplicatedUpdateFields=append(q.duplicatedUpdateFields,\"%s=%s\")",
+			c.DbName, g.duplicatedValue(c))
+		g.Pn("    return q")
+		g.Pn("}")
+		g.Pn("")
+
+		// 累加插入值
+		if c.IsNumeric() {
+			g.Pn("func (q *%sQuery)DuplicatedIncrement%s()*%sQuery{", t.GoName, c.GoName, t.GoName)
+			g.Pn("    q.duplicatedUpdateFields=append(q.duplicatedUpdateFields,\"%s=%s+%s\")",
+				c.DbName, c.DbName, g.duplicatedValue(c))
+			g.Pn("    return q")
+			g.Pn("}")
+			g.Pn("")
+		}
+
+		// 保留原值
+		g.Pn("func (q *%sQuery)DuplicatedKeep%s()*%sQuery{", t.GoName, c.GoName, t.GoName)
+		g.Pn("    q.duplicatedUpdateFields=append(q.duplicatedUpdateFields,\"%s=%s\")", c.DbName, c.DbName)
+		g.Pn("    return q")
+		g.Pn("}")
+		g.Pn("")
+
+		// 指定值
+		g.Pn("func (q *%sQuery)DuplicatedSet%s(v %s)*%sQuery{", t.GoName, c.GoName, c.GoTypeReal, t.GoName)
+		g.Pn("    q.duplicatedUpdateFields=append(q.duplicatedUpdateFields,\"%s=?\")", c.DbName)
+		g.Pn("    q.duplicatedUpdateParams=append(q.duplicatedUpdateParams,v)")
 		g.Pn("    return q")
 		g.Pn("}")
 		g.Pn("")
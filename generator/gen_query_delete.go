@@ -1,9 +1,50 @@
 package generator
 
 func (g *Generator) genQueryDelete(t *Table) {
+	if t.SoftDeleteColumn == nil {
+		g.Pn("func (q *%sQuery)Delete(ctx context.Context,tx *wrap.Tx)(result *wrap.Result,err error){", t.GoName)
+		g.genQueryContext(t, "Delete", "err")
+		g.genQueryShard("q.shard(q.dao.db.DB)", "return nil,err")
+		g.Pn("    query:=\"DELETE FROM %s WHERE \"+q.where.String()", t.DbName)
+		g.Pn("    return db.Executor(ctx,tx).Exec(ctx,query,q.whereParams...)")
+		g.Pn("}")
+		g.Pn("")
+		return
+	}
+
+	assignment := t.SoftDeleteAssignment()
+	if t.UpdateTimeColumn != nil {
+		assignment += "," + t.UpdateTimeColumn.DbName + "=?"
+	}
+
+	//软删除，HardDelete时物理删除
 	g.Pn("func (q *%sQuery)Delete(ctx context.Context,tx *wrap.Tx)(result *wrap.Result,err error){", t.GoName)
-	g.Pn("    query:=\"DELETE FROM %s WHERE \"+q.where.String()", t.DbName)
-	g.Pn("    return q.dao.db.Exec(ctx,tx,query,q.whereParams...)")
+	g.genQueryContext(t, "Delete", "err")
+	g.genQueryShard("q.shard(q.dao.db.DB)", "return nil,err")
+	// 条件为空时不附加过滤，避免整表删除
+	g.Pn("    where:=q.where.String()")
+	g.Pn("    if where!=\"\"&&q.softDeleteWhere!=\"\"{")
+	g.Pn("        where=\"(\"+where+\" ) AND \"+q.softDeleteWhere")
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    var query string")
+	g.Pn("    var params []interface{}")
+	g.Pn("    if q.hardDelete{")
+	g.Pn("        query=\"DELETE FROM %s WHERE \"+where", t.DbName)
+	g.Pn("    }else{")
+	g.Pn("        query=\"UPDATE %s SET %s WHERE \"+where", t.DbName, assignment)
+	if t.SoftDeleteColumn.GoTypeReal == "time.Time" || t.UpdateTimeColumn != nil {
+		g.Pn("        now:=q.dao.db.Now()")
+	}
+	if t.SoftDeleteColumn.GoTypeReal == "time.Time" {
+		g.Pn("        params=append(params,now.Truncate(%s))", t.SoftDeleteColumn.TimeTruncate())
+	}
+	if t.UpdateTimeColumn != nil {
+		g.Pn("        params=append(params,now.Truncate(%s))", t.UpdateTimeColumn.TimeTruncate())
+	}
+	g.Pn("    }")
+	g.Pn("    params=append(params,q.whereParams...)")
+	g.Pn("    return db.Executor(ctx,tx).Exec(ctx,query,params...)")
 	g.Pn("}")
 	g.Pn("")
 }
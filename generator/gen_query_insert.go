@@ -6,107 +6,287 @@ import (
 	"strings"
 )
 
+const (
+	insertPlain = iota
+	insertIgnore
+	insertReplace
+	insertDuplicated
+)
+
 func (g *Generator) genQueryInsert(t *Table) {
 	var fields []string
+	var insertParams []string
 	for _, c := range t.ColumnList {
-		if c.AutoIncrement || c.DbName == "create_time" || c.DbName == "update_time" {
+		if c.AutoIncrement || t.IsDbTimestampColumn(c) {
 			continue
 		}
 
 		fields = append(fields, c.DbName)
+		if t.IsTimestampColumn(c) {
+			insertParams = append(insertParams, c.TimeValue(timestampVar(c)))
+		} else {
+			insertParams = append(insertParams, "e."+c.GoName)
+		}
 	}
 
-	var insertParams []string
-	for _, c := range t.ColumnList {
-		if c.AutoIncrement || c.DbName == "create_time" || c.DbName == "update_time" {
-			continue
-		}
+	//INSERT
+	g.genInsert(t, "Insert", "INSERT INTO", fields, insertParams, insertPlain)
+	//BATCH INSERT
+	g.genBatchInsert(t, "BatchInsert", "INSERT INTO", fields, insertParams, insertPlain)
+	//INSERT IGNORE
+	g.genInsert(t, "InsertIgnore", "INSERT IGNORE INTO", fields, insertParams, insertIgnore)
+	//BATCH INSERT IGNORE
+	g.genBatchInsert(t, "BatchInsertIgnore", "INSERT IGNORE INTO", fields, insertParams, insertIgnore)
+	//REPLACE
+	g.genInsert(t, "Replace", "REPLACE INTO", fields, insertParams, insertReplace)
+	//BATCH REPLACE
+	g.genBatchInsert(t, "BatchReplace", "REPLACE INTO", fields, insertParams, insertReplace)
 
-		insertParams = append(insertParams, "e."+c.GoName)
+	genDuplicated := false
+	if (t.UniqueIndexList != nil && len(t.UniqueIndexList) > 0) ||
+		(t.UniqueUnionIndexList != nil && len(t.UniqueUnionIndexList) > 0) {
+		genDuplicated = true
 	}
+	if genDuplicated {
+		// INSERT DUPLICATED
+		g.genInsert(t, "InsertOnDuplicatedKeyUpdate", "INSERT INTO", fields, insertParams, insertDuplicated)
+		// BATCH INSERT [DUPLICATED]
+		g.genBatchInsert(t, "BatchInsertOnDuplicatedKeyUpdate", "INSERT INTO", fields, insertParams,
+			insertDuplicated)
+	}
+}
 
-	batchPlaceHolder := wrap.RepeatWithSeparator("?", len(fields), ",")
-
-	//INSERT
-	g.Pn("func (q *%sQuery)Insert(ctx context.Context,tx *wrap.Tx,e *%s)(result *wrap.Result,err error){",
-		t.GoName, t.GoName)
+func (g *Generator) genInsert(t *Table, name string, verb string, fields []string, insertParams []string,
+	kind int) {
+	g.Pn("func (q *%sQuery)%s(ctx context.Context,tx *wrap.Tx,e *%s)(result *wrap.Result,err error){",
+		t.GoName, name, t.GoName)
+	g.genQueryContext(t, name, "err")
+	g.genQueryShard("q.dao.entityShard(e)", "return nil,err")
+	g.genTimestampVars(t, true)
 	g.Pn("    query:=bytes.NewBufferString(\"\")")
 	g.Pn("    query.WriteString(\"%s\")",
-		fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", t.DbName,
+		fmt.Sprintf("%s %s (%s) VALUES (%s)", verb, t.DbName,
 			strings.Join(fields, ","),
 			wrap.RepeatWithSeparator("?", len(fields), ",")))
 	g.Pn("    params:=[]interface{} {%s}", strings.Join(insertParams, ","))
-	g.Pn("    return q.dao.db.Exec(ctx,tx,query.String(),params...)")
+	if kind == insertDuplicated {
+		g.genDuplicatedKeyUpdate(t, true)
+	}
+	ai := t.AutoIncrementColumn()
+	if t.CreateTimeColumn == nil && t.UpdateTimeColumn == nil && ai == nil {
+		g.Pn("    return db.Executor(ctx,tx).Exec(ctx,query.String(),params...)")
+		g.Pn("}")
+		g.Pn("")
+		return
+	}
+
+	g.Pn("    result,err=db.Executor(ctx,tx).Exec(ctx,query.String(),params...)")
+	g.Pn("    if err!=nil{")
+	g.Pn("        return nil,err")
+	g.Pn("    }")
+	g.Pn("")
+	switch kind {
+	case insertIgnore:
+		// 被忽略时未写入
+		g.Pn("    if n,_:=result.RowsAffected();n==1{")
+		g.genTimestampWriteBack(t, "e", true, true)
+		g.genInsertIdWriteBack(t, false)
+		g.Pn("    }")
+	case insertDuplicated:
+		// 影响行数为1时为插入，否则为更新
+		g.genTimestampWriteBack(t, "e", false, true)
+		if t.CreateTimeColumn != nil {
+			g.Pn("    if n,_:=result.RowsAffected();n==1{")
+			g.genTimestampWriteBack(t, "e", true, false)
+			g.Pn("    }")
+		}
+		// 更新时由LAST_INSERT_ID(id)返回已有记录的ID
+		g.genInsertIdWriteBack(t, true)
+	default:
+		g.genTimestampWriteBack(t, "e", true, true)
+		g.genInsertIdWriteBack(t, false)
+	}
+	g.Pn("")
+	g.Pn("    return result,nil")
 	g.Pn("}")
 	g.Pn("")
+}
 
-	//BATCH INSERT
-	g.Pn("func (q *%sQuery)BatchInsert(ctx context.Context,tx *wrap.Tx,list []*%s)"+
-		"(result *wrap.Result,err error){", t.GoName, t.GoName)
-	g.Pn("    query:=bytes.NewBufferString(\"\")")
-	g.Pn("    query.WriteString(\"INSERT INTO %s (%s) VALUES \")", t.DbName, strings.Join(fields, ","))
-	g.Pn("    query.WriteString(wrap.RepeatWithSeparator(\"(%s)\",len(list),\",\"))", batchPlaceHolder)
-	g.Pn("    params:=make([]interface{},len(list)*%d)", len(insertParams))
+func (g *Generator) genInsertIdWriteBack(t *Table, skipZero bool) {
+	ai := t.AutoIncrementColumn()
+	if ai == nil {
+		return
+	}
+
+	g.Pn("    id,err:=result.LastInsertId()")
+	g.Pn("    if err!=nil{")
+	g.Pn("        return nil,err")
+	g.Pn("    }")
+	if skipZero {
+		g.Pn("    if id!=0{")
+		g.Pn("        e.%s=%s(id)", ai.GoName, ai.GoType)
+		g.Pn("    }")
+	} else {
+		g.Pn("    e.%s=%s(id)", ai.GoName, ai.GoType)
+	}
+}
+
+// 按占位符上限和max_allowed_packet分批执行
+func (g *Generator) genBatchInsert(t *Table, name string, verb string, fields []string, insertParams []string,
+	kind int) {
+	batchPlaceHolder := wrap.RepeatWithSeparator("?", len(fields), ",")
+	prefix := fmt.Sprintf("%s %s (%s) VALUES ", verb, t.DbName, strings.Join(fields, ","))
+	rowParams := len(insertParams)
+	fixedParams := "0"
+	if kind == insertDuplicated {
+		fixedParams = "len(q.duplicatedUpdateParams)"
+	}
+	ai := t.AutoIncrementColumn()
+	writeIds := ai != nil && kind == insertPlain
+
+	if writeIds {
+		g.Pn("// %s 自增ID不连续(innodb_autoinc_lock_mode=2)且不支持RETURNING时，"+
+			"行已写入但不写回ID，此时result.IdsAssigned()为false", name)
+	}
+	g.Pn("func (q *%sQuery)%s(ctx context.Context,tx *wrap.Tx,list []*%s)"+
+		"(result *wrap.Result,err error){", t.GoName, name, t.GoName)
+	g.genQueryContext(t, name, "err")
+	g.genQueryShard("q.dao.entityShard(list...)", "return nil,err")
+	g.genTimestampVars(t, true)
+	g.Pn("    params:=make([]interface{},len(list)*%d)", rowParams)
 	g.Pn("    offset:=0")
 	g.Pn("    for _,e:=range list{")
 	for i, p := range insertParams {
 		g.Pn("        params[offset+%d]=%s", i, p)
 	}
-	g.Pn("    offset+=%d", len(insertParams))
+	g.Pn("    offset+=%d", rowParams)
 	g.Pn("    }")
 	g.Pn("")
-	g.Pn("    return q.dao.db.Exec(ctx,tx,query.String(),params...)")
-	g.Pn("}")
+	g.Pn("    batches,err:=db.SplitBatch(ctx,tx,params,%d,%s,%d)", rowParams, fixedParams, len(prefix))
+	g.Pn("    if err!=nil{")
+	g.Pn("        return nil,err")
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    return db.ExecBatch(ctx,tx,q.atomic,batches," +
+		"func(tx *wrap.Tx,b *wrap.Batch)(result *wrap.Result,err error){")
+	g.Pn("        list:=list[b.Start:b.End]")
+	g.Pn("        params:=params[b.Start*%d:b.End*%d:b.End*%d]", rowParams, rowParams, rowParams)
+	g.Pn("        query:=bytes.NewBufferString(\"\")")
+	g.Pn("        query.WriteString(\"%s\")", prefix)
+	g.Pn("        query.WriteString(wrap.RepeatWithSeparator(\"(%s)\",len(list),\",\"))", batchPlaceHolder)
+	if kind == insertDuplicated {
+		g.genDuplicatedKeyUpdate(t, false)
+	}
 	g.Pn("")
 
-	genDuplicated := false
-	if (t.UniqueIndexList != nil && len(t.UniqueIndexList) > 0) ||
-		(t.UniqueUnionIndexList != nil && len(t.UniqueUnionIndexList) > 0) {
-		genDuplicated = true
+	// 批量时无法区分每行是否被忽略或更新，仅写回确定的时间和ID
+	writeCreateTime := kind == insertPlain || kind == insertReplace
+	writeUpdateTime := kind != insertIgnore
+	if writeIds {
+		g.genBatchInsertIds(t, ai)
+	} else {
+		g.Pn("    result,err=db.Executor(ctx,tx).Exec(ctx,query.String(),params...)")
+		g.Pn("    if err!=nil{")
+		g.Pn("        return nil,err")
+		g.Pn("    }")
 	}
-	if genDuplicated {
-		// INSERT DUPLICATED
-		g.Pn("func (q *%sQuery)InsertOnDuplicatedKeyUpdate(ctx context.Context,tx *wrap.Tx,e *%s)"+
-			"(result *wrap.Result,err error){", t.GoName, t.GoName)
-		g.Pn("    query:=bytes.NewBufferString(\"\")")
-		g.Pn("    query.WriteString(\"%s\")",
-			fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", t.DbName,
-				strings.Join(fields, ","),
-				wrap.RepeatWithSeparator("?", len(fields), ",")))
-		g.Pn("    query.WriteString(\" ON DUPLICATED KEY UPDATE \")")
-		g.Pn("    if len(q.duplicatedUpdateFields)>0{")
-		g.Pn("        query.WriteString(strings.Join(q.duplicatedUpdateFields,\",\"))")
-		g.Pn("        query.WriteString(\",\")")
+	g.Pn("")
+	if (writeCreateTime && t.CreateTimeColumn != nil) || (writeUpdateTime && t.UpdateTimeColumn != nil) {
+		g.Pn("    for _,e:=range list{")
+		g.genTimestampWriteBack(t, "e", writeCreateTime, writeUpdateTime)
 		g.Pn("    }")
-		g.Pn("    query.WriteString(\"update_time=now()\")")
-		g.Pn("    params:=[]interface{} {%s}", strings.Join(insertParams, ","))
-		g.Pn("    return q.dao.db.Exec(ctx,tx,query.String(),params...)")
-		g.Pn("}")
 		g.Pn("")
+	}
+	g.Pn("        return result,nil")
+	g.Pn("    })")
+	g.Pn("}")
+	g.Pn("")
+}
 
-		// BATCH INSERT [DUPLICATED]
-		g.Pn("func (q *%sQuery)BatchInsertOnDuplicatedKeyUpdate(ctx context.Context,tx *wrap.Tx,list []*%s)"+
-			"(result *wrap.Result,err error){", t.GoName, t.GoName)
-		g.Pn("    query:=bytes.NewBufferString(\"\")")
-		g.Pn("    query.WriteString(\"INSERT INTO %s (%s) VALUES \")", t.DbName, strings.Join(fields, ","))
-		g.Pn("    query.WriteString(wrap.RepeatWithSeparator(\"(%s)\",len(list),\",\"))", batchPlaceHolder)
-		g.Pn("    query.WriteString(\" ON DUPLICATED KEY UPDATE\")")
-		g.Pn("    if len(q.duplicatedUpdateFields)>0{")
+// 支持RETURNING时直接取回ID，否则在自增ID连续时按首个ID推算
+func (g *Generator) genBatchInsertIds(t *Table, ai *Column) {
+	g.Pn("    autoIncrement,err:=db.AutoIncrement(ctx,tx)")
+	g.Pn("    if err!=nil{")
+	g.Pn("        return nil,err")
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    if autoIncrement.Returning{")
+	g.Pn("        query.WriteString(\" RETURNING %s\")", ai.DbName)
+	g.Pn("        rows,err:=db.Executor(ctx,tx).Query(ctx,query.String(),params...)")
+	g.Pn("        if err!=nil{")
+	g.Pn("            return nil,err")
+	g.Pn("        }")
+	g.Pn("        defer rows.Close()")
+	g.Pn("        count:=0")
+	g.Pn("        for ;count<len(list)&&rows.Next();count++{")
+	g.Pn("            err=rows.Scan(&list[count].%s)", ai.GoName)
+	g.Pn("            if err!=nil{")
+	g.Pn("                return nil,err")
+	g.Pn("            }")
+	g.Pn("        }")
+	g.Pn("        if err=rows.Err();err!=nil{")
+	g.Pn("            return nil,err")
+	g.Pn("        }")
+	g.Pn("        var lastInsertId int64")
+	g.Pn("        if count>0{")
+	g.Pn("            lastInsertId=int64(list[0].%s)", ai.GoName)
+	g.Pn("        }")
+	g.Pn("        result=wrap.NewResult(lastInsertId,int64(count))")
+	g.Pn("    }else{")
+	g.Pn("        result,err=db.Executor(ctx,tx).Exec(ctx,query.String(),params...)")
+	g.Pn("        if err!=nil{")
+	g.Pn("            return nil,err")
+	g.Pn("        }")
+	g.Pn("")
+	g.Pn("        ids,err:=db.InsertIds(ctx,tx,result,len(list))")
+	g.Pn("        if errors.Is(err,wrap.ErrIdsNotContiguous){")
+	g.Pn("            result.MarkIdsUnassigned()")
+	g.Pn("        }else if err!=nil{")
+	g.Pn("            return nil,err")
+	g.Pn("        }")
+	g.Pn("        for i,id:=range ids{")
+	g.Pn("            list[i].%s=%s(id)", ai.GoName, ai.GoType)
+	g.Pn("        }")
+	g.Pn("    }")
+}
+
+// ON DUPLICATE KEY UPDATE子句，未指定字段时生成空操作赋值
+func (g *Generator) genDuplicatedKeyUpdate(t *Table, single bool) {
+	var assignments []string
+	if ai := t.AutoIncrementColumn(); ai != nil && single {
+		assignments = append(assignments, fmt.Sprintf("\"%s=LAST_INSERT_ID(%s)\"", ai.DbName, ai.DbName))
+	}
+	if t.UpdateTimeColumn != nil {
+		assignments = append(assignments, fmt.Sprintf("\"%s=%s\"",
+			t.UpdateTimeColumn.DbName, g.duplicatedValue(t.UpdateTimeColumn)))
+	}
+
+	if g.RowAlias {
+		g.Pn("    query.WriteString(\" AS %s\")", duplicatedRowAlias)
+	}
+	g.Pn("    query.WriteString(\" ON DUPLICATE KEY UPDATE \")")
+	if len(assignments) > 0 {
+		// 复制后追加，避免写入q.duplicatedUpdateFields的剩余容量
+		g.Pn("    query.WriteString(strings.Join(append(append([]string(nil),q.duplicatedUpdateFields...),%s),\",\"))",
+			strings.Join(assignments, ","))
+	} else {
+		noop := t.duplicatedNoopColumn()
+		g.Pn("    if len(q.duplicatedUpdateFields)==0{")
+		g.Pn("        query.WriteString(\"%s=%s\")", noop.DbName, noop.DbName)
+		g.Pn("    }else{")
 		g.Pn("        query.WriteString(strings.Join(q.duplicatedUpdateFields,\",\"))")
-		g.Pn("        query.WriteString(\",\")")
 		g.Pn("    }")
-		g.Pn("    query.WriteString(\"update_time=now()\")")
-		g.Pn("    params:=make([]interface{},len(list)*%d)", len(insertParams))
-		g.Pn("    offset:=0")
-		g.Pn("    for _,e:=range list{")
-		for i, p := range insertParams {
-			g.Pn("        params[offset+%d]=%s", i, p)
-		}
-		g.Pn("    offset+=%d", len(insertParams))
-		g.Pn("    }")
-		g.Pn("")
-		g.Pn("    return q.dao.db.Exec(ctx,tx,query.String(),params...)")
-		g.Pn("}")
-		g.Pn("")
 	}
+	g.Pn("    params=append(params,q.duplicatedUpdateParams...)")
 }
+
+// 新插入行的值，MySQL 8.0.19起可使用行别名代替VALUES()
+func (g *Generator) duplicatedValue(c *Column) string {
+	if g.RowAlias {
+		return duplicatedRowAlias + "." + c.DbName
+	}
+
+	return "VALUES(" + c.DbName + ")"
+}
+
+const duplicatedRowAlias = "new"
@@ -10,14 +10,28 @@ func (g *Generator) genQuerySelect(t *Table) {
 		fields = append(fields, c.DbName)
 	}
 
-	//查询单条纪录
+	//查询单条纪录，跨分片时合并各分片结果
 	g.Pn("func (q *%sQuery)Select(ctx context.Context,tx *wrap.Tx) (e *%s,err error) {",
 		t.GoName, t.GoName)
+	g.genQueryContext(t, "Select", "err")
 	g.Pn("    if !q.hasLimit{")
 	g.Pn("        q.limitCount=1")
 	g.Pn("        q.hasLimit=true")
 	g.Pn("    }")
 	g.Pn("")
+	g.Pn("    shards,err:=q.fanOutShards(ctx,tx,q.dao.db.DB)")
+	g.Pn("    if err!=nil{")
+	g.Pn("        return nil,err")
+	g.Pn("    }")
+	g.Pn("    if len(shards)>1{")
+	g.Pn("        list,err:=q.SelectList(ctx,tx)")
+	g.Pn("        if err!=nil||len(list)==0{")
+	g.Pn("            return nil,err")
+	g.Pn("        }")
+	g.Pn("        return list[0],nil")
+	g.Pn("    }")
+	g.Pn("    db:=shards[0]")
+	g.Pn("")
 	g.Pn("    queryString,params:=q.buildSelectQuery()")
 	g.Pn("    query:=bytes.NewBufferString(\"\")")
 	g.Pn("    if len(q.getFields)==0{")
@@ -29,9 +43,9 @@ func (g *Generator) genQuerySelect(t *Table) {
 	g.Pn("    }")
 	g.Pn("    query.WriteString(queryString)")
 	g.Pn("    e=&%s{}", t.GoName)
-	g.Pn("    row:=q.dao.db.QueryRow(ctx,tx,query.String(),params...)")
+	g.Pn("    row:=db.Executor(ctx,tx).QueryRow(ctx,query.String(),params...)")
 	g.Pn("    err=row.Scan(%s)", strings.Join(scanParams, ","))
-	g.Pn("    if err==wrap.ErrNoRows{")
+	g.Pn("    if errors.Is(err,wrap.ErrNoRows){")
 	g.Pn("        return nil,nil")
 	g.Pn("    }")
 	g.Pn("")
@@ -39,10 +53,21 @@ func (g *Generator) genQuerySelect(t *Table) {
 	g.Pn("}")
 	g.Pn("")
 
-	//查询列表
+	//查询列表，跨分片时各分片取前limitStartIncluded+limitCount条，合并排序后分页
 	g.Pn("func (q *%sQuery)SelectList(ctx context.Context,tx *wrap.Tx) (list []*%s,err error) {",
 		t.GoName, t.GoName)
+	g.genQueryContext(t, "SelectList", "err")
+	g.Pn("    shards,err:=q.fanOutShards(ctx,tx,q.dao.db.DB)")
+	g.Pn("    if err!=nil{")
+	g.Pn("        return nil,err")
+	g.Pn("    }")
+	g.Pn("    limitStart,limitCount:=q.limitStartIncluded,q.limitCount")
+	g.Pn("    if len(shards)>1&&q.hasLimit{")
+	g.Pn("        q.limitStartIncluded,q.limitCount=0,limitStart+limitCount")
+	g.Pn("    }")
+	g.Pn("")
 	g.Pn("    queryString,params:=q.buildSelectQuery()")
+	g.Pn("    q.limitStartIncluded,q.limitCount=limitStart,limitCount")
 	g.Pn("    query:=bytes.NewBufferString(\"\")")
 	g.Pn("    if len(q.getFields)==0{")
 	g.Pn("        query.WriteString(\"SELECT %s FROM %s \")", strings.Join(fields, ","), t.DbName)
@@ -52,36 +77,87 @@ func (g *Generator) genQuerySelect(t *Table) {
 	g.Pn("        query.WriteString(\" FROM %s \")", t.DbName)
 	g.Pn("    }")
 	g.Pn("    query.WriteString(queryString)")
-	g.Pn("    rows,err:=q.dao.db.Query(ctx,tx,query.String(),params...)")
+	g.Pn("    results:=make([][]*%s,len(shards))", t.GoName)
+	g.Pn("    err=wrap.FanOut(ctx,shards,func(ctx context.Context,i int,db *wrap.DB) error {")
+	g.Pn("        rows,err:=db.Executor(ctx,tx).Query(ctx,query.String(),params...)")
+	g.Pn("        if err!=nil{")
+	g.Pn("            return err")
+	g.Pn("        }")
+	g.Pn("        defer rows.Close()")
+	g.Pn("        for rows.Next(){")
+	g.Pn("            e:=%s{}", t.GoName)
+	g.Pn("            err=rows.Scan(%s)", strings.Join(scanParams, ","))
+	g.Pn("            if err!=nil{")
+	g.Pn("                return err")
+	g.Pn("            }")
+	g.Pn("            results[i]=append(results[i],&e)")
+	g.Pn("        }")
+	g.Pn("")
+	g.Pn("        return rows.Err()")
+	g.Pn("    })")
 	g.Pn("    if err!=nil{")
 	g.Pn("        return nil,err")
 	g.Pn("    }")
-	g.Pn("    for rows.Next(){")
-	g.Pn("        e:=%s{}", t.GoName)
-	g.Pn("        err=rows.Scan(%s)", strings.Join(scanParams, ","))
-	g.Pn("        if err!=nil{")
-	g.Pn("            return nil,err")
-	g.Pn("        }")
-	g.Pn("        list=append(list,&e)")
+	g.Pn("    if len(shards)==1{")
+	g.Pn("        return results[0],nil")
 	g.Pn("    }")
-	g.Pn("    if rows.Err()!=nil{")
-	g.Pn("        err=rows.Err()")
-	g.Pn("        return nil,err")
+	g.Pn("")
+	g.Pn("    for _,r:=range results{")
+	g.Pn("        list=append(list,r...)")
+	g.Pn("    }")
+	g.Pn("    if len(q.orderByFields)>0{")
+	g.Pn("        sort.SliceStable(list,func(i,j int) bool {")
+	g.Pn("            return q.less(list[i],list[j])")
+	g.Pn("        })")
+	g.Pn("    }")
+	g.Pn("    if q.hasLimit{")
+	g.Pn("        if limitStart>=int64(len(list)){")
+	g.Pn("            return nil,nil")
+	g.Pn("        }")
+	g.Pn("        if limitStart+limitCount<int64(len(list)){")
+	g.Pn("            list=list[:limitStart+limitCount]")
+	g.Pn("        }")
+	g.Pn("        list=list[limitStart:]")
 	g.Pn("    }")
 	g.Pn("")
 	g.Pn("    return list,nil")
 	g.Pn("}")
 	g.Pn("")
 
-	//查询数量
+	//跨分片合并时按ORDER BY字段比较，排序字段须在查询字段中。字符串按字节比较，与列的排序规则可能不一致
+	g.Pn("func (q *%sQuery)less(a *%s,b *%s) bool {", t.GoName, t.GoName, t.GoName)
+	g.Pn("    for i,field:=range q.orderByFields{")
+	g.Pn("        va,_:=q.dao.fieldValue(a,field)")
+	g.Pn("        vb,_:=q.dao.fieldValue(b,field)")
+	g.Pn("        if c:=wrap.CompareValues(va,vb);c!=0{")
+	g.Pn("            return (c<0)==q.orderByOrders[i]")
+	g.Pn("        }")
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    return false")
+	g.Pn("}")
+	g.Pn("")
+
+	//查询数量，跨分片时求和
 	g.Pn("func (q *%sQuery)SelectCount(ctx context.Context,tx *wrap.Tx) (count int64,err error) {",
 		t.GoName)
+	g.genQueryContext(t, "SelectCount", "err")
+	g.Pn("    shards,err:=q.fanOutShards(ctx,tx,q.dao.db.DB)")
+	g.Pn("    if err!=nil{")
+	g.Pn("        return 0,err")
+	g.Pn("    }")
+	g.Pn("")
 	g.Pn("    queryString,params:=q.buildSelectQuery()")
 	g.Pn("    query:=bytes.NewBufferString(\"\")")
 	g.Pn("    query.WriteString(\"SELECT COUNT(*) FROM %s \")", t.DbName)
 	g.Pn("    query.WriteString(queryString)")
-	g.Pn("    row:=q.dao.db.QueryRow(ctx,tx,query.String(),params...)")
-	g.Pn("    err=row.Scan(&count)")
+	g.Pn("    counts:=make([]int64,len(shards))")
+	g.Pn("    err=wrap.FanOut(ctx,shards,func(ctx context.Context,i int,db *wrap.DB) error {")
+	g.Pn("        return db.Executor(ctx,tx).QueryRow(ctx,query.String(),params...).Scan(&counts[i])")
+	g.Pn("    })")
+	g.Pn("    for _,n:=range counts{")
+	g.Pn("        count+=n")
+	g.Pn("    }")
 	g.Pn("")
 	g.Pn("    return count,err")
 	g.Pn("}")
@@ -90,6 +166,8 @@ func (g *Generator) genQuerySelect(t *Table) {
 	//分组查询
 	g.Pn("func (q *%sQuery)SelectGroupBy(ctx context.Context,tx *wrap.Tx,withCount bool) "+
 		"(rows *wrap.Rows,err error) {", t.GoName)
+	g.genQueryContext(t, "SelectGroupBy", "err")
+	g.genQueryShard("q.shard(q.dao.db.DB)", "return nil,err")
 	g.Pn("    queryString,params:=q.buildSelectQuery()")
 	g.Pn("    query:=bytes.NewBufferString(\"\")")
 	g.Pn("    query.WriteString(\"SELECT \")")
@@ -100,12 +178,14 @@ func (g *Generator) genQuerySelect(t *Table) {
 	g.Pn("    query.WriteString(\" FROM %s \")", t.DbName)
 	g.Pn("    query.WriteString(queryString)")
 	g.Pn("")
-	g.Pn("    return q.dao.db.Query(ctx,tx,query.String(),params...)")
+	g.Pn("    return db.Executor(ctx,tx).Query(ctx,query.String(),params...)")
 	g.Pn("}")
 	g.Pn("")
 
 	//查询单条纪录（返回指定字段）
 	g.Pn("func (q *%sQuery)SelectRow(ctx context.Context,tx *wrap.Tx) (row *wrap.Row) {", t.GoName)
+	g.genQueryContext(t, "SelectRow", "row.Err()")
+	g.genQueryShard("q.shard(q.dao.db.DB)", "return wrap.NewErrorRow(err)")
 	g.Pn("    if !q.hasLimit{")
 	g.Pn("        q.limitCount=1")
 	g.Pn("        q.hasLimit=true")
@@ -117,19 +197,21 @@ func (g *Generator) genQuerySelect(t *Table) {
 	g.Pn("    query.WriteString(strings.Join(q.getFields,\",\"))")
 	g.Pn("    query.WriteString(\" FROM %s \")", t.DbName)
 	g.Pn("    query.WriteString(queryString)")
-	g.Pn("    return q.dao.db.QueryRow(ctx,tx,query.String(),params...)")
+	g.Pn("    return db.Executor(ctx,tx).QueryRow(ctx,query.String(),params...)")
 	g.Pn("}")
 	g.Pn("")
 
 	//查询多条纪录（返回指定字段）
 	g.Pn("func (q *%sQuery)SelectRows(ctx context.Context,tx *wrap.Tx) (rows *wrap.Rows,err error) {", t.GoName)
+	g.genQueryContext(t, "SelectRows", "err")
+	g.genQueryShard("q.shard(q.dao.db.DB)", "return nil,err")
 	g.Pn("    queryString,params:=q.buildSelectQuery()")
 	g.Pn("    query:=bytes.NewBufferString(\"\")")
 	g.Pn("    query.WriteString(\"SELECT \")")
 	g.Pn("    query.WriteString(strings.Join(q.getFields,\",\"))")
 	g.Pn("    query.WriteString(\" FROM %s \")", t.DbName)
 	g.Pn("    query.WriteString(queryString)")
-	g.Pn("    return q.dao.db.Query(ctx,tx,query.String(),params...)")
+	g.Pn("    return db.Executor(ctx,tx).Query(ctx,query.String(),params...)")
 	g.Pn("}")
 	g.Pn("")
 }
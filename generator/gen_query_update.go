@@ -1,7 +1,12 @@
 package generator
 
+import "strings"
+
 func (g *Generator) genQueryUpdate(t *Table) {
 	g.Pn("func (q *%sQuery)Update(ctx context.Context,tx *wrap.Tx)(result *wrap.Result,err error){", t.GoName)
+	g.genQueryContext(t, "Update", "err")
+	g.genQueryShard("q.shard(q.dao.db.DB)", "return nil,err")
+	g.genTimestampVars(t, false)
 	g.Pn("    query:=bytes.NewBufferString(\"\")")
 	g.Pn("    var params []interface{}")
 	g.Pn("    params=append(params,q.updateParams...)")
@@ -10,16 +15,154 @@ func (g *Generator) genQueryUpdate(t *Table) {
 	g.Pn("    for i,v:=range q.updateFields{")
 	g.Pn("        updateItems[i]=v+\"=?\"")
 	g.Pn("    }")
+	if t.UpdateTimeColumn != nil {
+		g.Pn("    updateItems=append(updateItems,\"%s=?\")", t.UpdateTimeColumn.DbName)
+		g.Pn("    params=append(params,%s)", t.UpdateTimeColumn.TimeValue(timestampVar(t.UpdateTimeColumn)))
+	}
 	g.Pn("    query.WriteString(strings.Join(updateItems,\",\"))")
-	g.Pn("    query.WriteString(\",update_time=now()\")")
-	g.Pn("    where:=q.where.String()")
+	g.Pn("    where,whereParams:=q.buildWhere()")
 	g.Pn("    if where!=\"\"{")
 	g.Pn("        query.WriteString(\" WHERE \")")
 	g.Pn("        query.WriteString(where)")
-	g.Pn("        params=append(params,q.whereParams...)")
+	g.Pn("        params=append(params,whereParams...)")
 	g.Pn("    }")
 	g.Pn("    ")
-	g.Pn("    return q.dao.db.Exec(ctx,tx,query.String(),params...)")
+	g.Pn("    return db.Executor(ctx,tx).Exec(ctx,query.String(),params...)")
+	g.Pn("}")
+	g.Pn("")
+
+	g.genQueryBatchUpdate(t)
+}
+
+// 批量更新，每行使用各自的值：SET col=CASE id WHEN ? THEN ? ... END WHERE id IN (...)
+func (g *Generator) genQueryBatchUpdate(t *Table) {
+	pk := t.PrimaryColumn
+	if pk == nil {
+		return
+	}
+
+	var columns []*Column
+	for _, c := range t.ColumnList {
+		if c == pk || c.AutoIncrement || t.IsTimestampColumn(c) {
+			continue
+		}
+		columns = append(columns, c)
+	}
+	if len(columns) == 0 {
+		return
+	}
+
+	// 字段名常量
+	g.Pn("const (")
+	for _, c := range columns {
+		g.Pn("    %sField%s=\"%s\"", t.GoName, c.GoName, c.DbName)
+	}
+	g.Pn(")")
+	g.Pn("")
+
+	// 按字段名取值
+	g.Pn("func (q *%sQuery)batchUpdateValue(e *%s,field string)(v interface{},err error){", t.GoName, t.GoName)
+	g.Pn("    switch field{")
+	for _, c := range columns {
+		g.Pn("    case \"%s\":", c.DbName)
+		g.Pn("        return e.%s,nil", c.GoName)
+	}
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    return nil,fmt.Errorf(\"%s batch update unknown field %%s\",field)", t.DbName)
+	g.Pn("}")
+	g.Pn("")
+
+	var allFields []string
+	for _, c := range columns {
+		allFields = append(allFields, "\""+c.DbName+"\"")
+	}
+
+	// 未指定字段时更新全部字段
+	g.Pn("func (q *%sQuery)BatchUpdate(ctx context.Context,tx *wrap.Tx,list []*%s,fields ...string)"+
+		"(result *wrap.Result,err error){", t.GoName, t.GoName)
+	g.genQueryContext(t, "BatchUpdate", "err")
+	g.genQueryShard("q.dao.entityShard(list...)", "return nil,err")
+	g.Pn("    if len(fields)==0{")
+	g.Pn("        fields=[]string{%s}", strings.Join(allFields, ","))
+	g.Pn("    }")
+	g.genTimestampVars(t, false)
+	g.Pn("")
+	g.Pn("    rowParams:=1+2*len(fields)")
+	g.Pn("    values:=make([]interface{},len(list)*rowParams)")
+	g.Pn("    for i,e:=range list{")
+	g.Pn("        offset:=i*rowParams")
+	g.Pn("        values[offset]=e.%s", pk.GoName)
+	g.Pn("        for j,field:=range fields{")
+	g.Pn("            values[offset+1+2*j]=e.%s", pk.GoName)
+	g.Pn("            values[offset+2+2*j],err=q.batchUpdateValue(e,field)")
+	g.Pn("            if err!=nil{")
+	g.Pn("                return nil,err")
+	g.Pn("            }")
+	g.Pn("        }")
+	g.Pn("    }")
+	g.Pn("")
+	fixedParams := 0
+	if t.UpdateTimeColumn != nil {
+		fixedParams = 1
+	}
+	g.Pn("    batches,err:=db.SplitBatch(ctx,tx,values,rowParams,%d,len(\"UPDATE %s SET \")+32*len(fields))",
+		fixedParams, t.DbName)
+	g.Pn("    if err!=nil{")
+	g.Pn("        return nil,err")
+	g.Pn("    }")
+	g.Pn("")
+	g.Pn("    return db.ExecBatch(ctx,tx,q.atomic,batches," +
+		"func(tx *wrap.Tx,b *wrap.Batch)(result *wrap.Result,err error){")
+	g.Pn("        list:=list[b.Start:b.End]")
+	g.Pn("        values:=values[b.Start*rowParams:b.End*rowParams]")
+	if fixedParams > 0 {
+		g.Pn("        params:=make([]interface{},0,len(values)+%d)", fixedParams)
+	} else {
+		g.Pn("        params:=make([]interface{},0,len(values))")
+	}
+	g.Pn("        query:=bytes.NewBufferString(\"\")")
+	g.Pn("        query.WriteString(\"UPDATE %s SET \")", t.DbName)
+	g.Pn("        for j,field:=range fields{")
+	g.Pn("            if j>0{")
+	g.Pn("                query.WriteString(\",\")")
+	g.Pn("            }")
+	g.Pn("            query.WriteString(field)")
+	g.Pn("            query.WriteString(\"=CASE %s\")", pk.DbName)
+	g.Pn("            for i:=range list{")
+	g.Pn("                query.WriteString(\" WHEN ? THEN ?\")")
+	g.Pn("                params=append(params,values[i*rowParams+1+2*j],values[i*rowParams+2+2*j])")
+	g.Pn("            }")
+	g.Pn("            query.WriteString(\" END\")")
+	g.Pn("        }")
+	if t.UpdateTimeColumn != nil {
+		g.Pn("        query.WriteString(\",%s=?\")", t.UpdateTimeColumn.DbName)
+		g.Pn("        params=append(params,%s)", t.UpdateTimeColumn.TimeValue(timestampVar(t.UpdateTimeColumn)))
+	}
+	g.Pn("        query.WriteString(\" WHERE %s IN (\")", pk.DbName)
+	g.Pn("        query.WriteString(wrap.RepeatWithSeparator(\"?\",len(list),\",\"))")
+	g.Pn("        query.WriteString(\")\")")
+	g.Pn("        for i:=range list{")
+	g.Pn("            params=append(params,values[i*rowParams])")
+	g.Pn("        }")
+	g.Pn("        if q.softDeleteWhere!=\"\"{")
+	g.Pn("            query.WriteString(\" AND \")")
+	g.Pn("            query.WriteString(q.softDeleteWhere)")
+	g.Pn("        }")
+	g.Pn("")
+	g.Pn("        result,err=db.Executor(ctx,tx).Exec(ctx,query.String(),params...)")
+	g.Pn("        if err!=nil{")
+	g.Pn("            return nil,err")
+	g.Pn("        }")
+	g.Pn("")
+	if t.UpdateTimeColumn != nil {
+		g.Pn("        for _,e:=range list{")
+		g.genTimestampWriteBack(t, "e", false, true)
+		g.Pn("        }")
+		g.Pn("")
+	}
+	g.Pn("        return result,nil")
+	g.Pn("    })")
 	g.Pn("}")
 	g.Pn("")
 }
@@ -0,0 +1,39 @@
+package generator
+
+import "strings"
+
+func timestampVar(c *Column) string {
+	return strings.ToLower(c.GoName[:1]) + c.GoName[1:]
+}
+
+// 从时钟取当前时间，按列精度截断
+func (g *Generator) genTimestampVars(t *Table, create bool) {
+	var columns []*Column
+	if create && t.CreateTimeColumn != nil {
+		columns = append(columns, t.CreateTimeColumn)
+	}
+	if t.UpdateTimeColumn != nil {
+		columns = append(columns, t.UpdateTimeColumn)
+	}
+	if len(columns) == 0 {
+		return
+	}
+
+	g.Pn("    now:=q.dao.db.Now()")
+	for _, c := range columns {
+		g.Pn("    %s:=now.Truncate(%s)", timestampVar(c), c.TimeTruncate())
+	}
+}
+
+// 执行成功后写回实体
+func (g *Generator) genTimestampWriteBack(t *Table, e string, create bool, update bool) {
+	if create && t.CreateTimeColumn != nil {
+		c := t.CreateTimeColumn
+		g.Pn("    %s.%s=%s", e, c.GoName, c.TimeValue(timestampVar(c)))
+	}
+
+	if update && t.UpdateTimeColumn != nil {
+		c := t.UpdateTimeColumn
+		g.Pn("    %s.%s=%s", e, c.GoName, c.TimeValue(timestampVar(c)))
+	}
+}
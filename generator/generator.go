@@ -11,12 +11,22 @@ type Generator struct {
 	DbName    string
 	TableList []*Table
 
+	// 软删除列名，按顺序匹配第一个存在的列
+	SoftDeleteColumnNameList []string
+
+	// ON DUPLICATE KEY UPDATE使用MySQL 8行别名代替VALUES()
+	RowAlias bool
+
+	// create_time/update_time有CURRENT_TIMESTAMP默认值时由数据库维护，不以时钟赋值也不写回实体
+	DbTimestamps bool
+
 	buf *bytes.Buffer
 }
 
 func NewGenerator() *Generator {
 	g := &Generator{}
 	g.TableList = make([]*Table, 0)
+	g.SoftDeleteColumnNameList = []string{"deleted_at", "is_deleted"}
 	g.buf = bytes.NewBufferString("")
 
 	return g
@@ -0,0 +1,26 @@
+package generator
+
+import (
+	"os"
+	"testing"
+)
+
+// internal/ormtest中的生成代码须与当前生成器的输出一致，其测试覆盖生成代码的运行行为
+func TestGenOrmTest(t *testing.T) {
+	schema, err := os.ReadFile("internal/ormtest/schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile("internal/ormtest/orm.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orm, err := NewGenerator().Gen(string(schema), "ormtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orm != string(expected) {
+		t.Fatal("internal/ormtest/orm.go is out of date, regenerate it as described in schema.sql")
+	}
+}
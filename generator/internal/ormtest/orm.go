@@ -0,0 +1,1536 @@
+package ormtest
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/NeuronFramework/sql/wrap"
+	"github.com/go-sql-driver/mysql"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+var _ = sql.ErrNoRows
+var _ = mysql.ErrOldProtocol
+
+type QueryBase struct {
+	tableName              string
+	where                  *bytes.Buffer
+	whereParams            []interface{}
+	groupByFields          []string
+	groupByOrders          []bool
+	orderByFields          []string
+	orderByOrders          []bool
+	hasLimit               bool
+	limitStartIncluded     int64
+	limitCount             int64
+	forUpdate              bool
+	forShare               bool
+	updateFields           []string
+	updateParams           []interface{}
+	getFields              []string
+	duplicatedUpdateFields []string
+	duplicatedUpdateParams []interface{}
+	softDeleteWhere        string
+	atomic                 bool
+	sharding               *wrap.Sharding
+	shardKeys              []interface{}
+	shardKeyUnknown        bool
+	allShards              bool
+}
+
+func (q *QueryBase) addShardKey(column string, values ...interface{}) {
+	if q.sharding != nil && q.sharding.Column == column {
+		q.shardKeys = append(q.shardKeys, values...)
+	}
+}
+
+func (q *QueryBase) shards(db *wrap.DB) ([]*wrap.DB, error) {
+	if q.sharding == nil {
+		return []*wrap.DB{db}, nil
+	}
+	if q.allShards {
+		return q.sharding.Shards, nil
+	}
+	if q.shardKeyUnknown {
+		return nil, wrap.ErrMissingShardKey
+	}
+
+	return q.sharding.Route(q.shardKeys)
+}
+
+func (q *QueryBase) shard(db *wrap.DB) (*wrap.DB, error) {
+	shards, err := q.shards(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(shards) > 1 {
+		return nil, wrap.ErrCrossShard
+	}
+
+	return shards[0], nil
+}
+
+func (q *QueryBase) fanOutShards(ctx context.Context, tx *wrap.Tx, db *wrap.DB) ([]*wrap.DB, error) {
+	shards, err := q.shards(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(shards) > 1 && (tx != nil || wrap.TxFromContext(ctx) != nil) {
+		return nil, wrap.ErrCrossShard
+	}
+
+	return shards, nil
+}
+
+func (q *QueryBase) buildWhere() (where string, params []interface{}) {
+	where = q.where.String()
+	params = append(params, q.whereParams...)
+	if q.softDeleteWhere != "" {
+		if where == "" {
+			where = q.softDeleteWhere
+		} else {
+			where = "(" + where + " ) AND " + q.softDeleteWhere
+		}
+	}
+
+	return where, params
+}
+
+func (q *QueryBase) buildSelectQuery() (queryString string, params []interface{}) {
+	query := bytes.NewBufferString("")
+
+	where, whereParams := q.buildWhere()
+	if where != "" {
+		query.WriteString(" WHERE ")
+		query.WriteString(where)
+		params = append(params, whereParams...)
+	}
+
+	groupByCount := len(q.groupByFields)
+	if groupByCount > 0 {
+		groupByItems := make([]string, groupByCount)
+		for i, v := range q.groupByFields {
+			if q.groupByOrders[i] {
+				groupByItems[i] = v + " ASC"
+			} else {
+				groupByItems[i] = v + " DESC"
+			}
+		}
+		query.WriteString(" GROUP BY ")
+		query.WriteString(strings.Join(groupByItems, ","))
+	}
+
+	var orderByItems []string
+	orderByCount := len(q.orderByFields)
+	if orderByCount > 0 {
+		orderByItems = make([]string, orderByCount)
+		for i, v := range q.orderByFields {
+			if q.orderByOrders[i] {
+				orderByItems[i] = v + " ASC"
+			} else {
+				orderByItems[i] = v + " DESC"
+			}
+		}
+		query.WriteString(" ORDER BY ")
+		query.WriteString(strings.Join(orderByItems, ","))
+	}
+
+	if q.hasLimit {
+		query.WriteString(fmt.Sprintf(" LIMIT %d,%d", q.limitStartIncluded, q.limitCount))
+	}
+
+	if q.limitStartIncluded > 128 {
+		query = bytes.NewBufferString(fmt.Sprintf("INNER JOIN (SELECT id FROM %s %s) AS t USING(id)", q.tableName, query.String()))
+		if len(orderByItems) > 0 {
+			query.WriteString(" ORDER BY ")
+			query.WriteString(strings.Join(orderByItems, ","))
+		}
+	}
+
+	if q.forUpdate {
+		query.WriteString(" FOR UPDATE")
+	}
+
+	if q.forShare {
+		query.WriteString(" LOCK IN SHARE MODE")
+	}
+
+	return query.String(), params
+}
+
+type TenantOrder struct {
+	Id         int64          //size=20
+	TenantId   string         //size=64
+	OrderNo    string         //size=64
+	Amount     int64          //size=20
+	Remark     sql.NullString //size=256
+	CreateTime time.Time
+	UpdateTime time.Time
+}
+
+type TenantOrderQuery struct {
+	QueryBase
+	dao *TenantOrderDao
+}
+
+func (q *TenantOrderQuery) Left() *TenantOrderQuery {
+	q.where.WriteString(" (")
+	return q
+}
+
+func (q *TenantOrderQuery) Right() *TenantOrderQuery {
+	q.where.WriteString(" )")
+	return q
+}
+
+func (q *TenantOrderQuery) And() *TenantOrderQuery {
+	q.where.WriteString(" AND")
+	return q
+}
+
+func (q *TenantOrderQuery) Or() *TenantOrderQuery {
+	q.where.WriteString(" OR")
+	q.shardKeyUnknown = true
+	return q
+}
+
+func (q *TenantOrderQuery) Not() *TenantOrderQuery {
+	q.where.WriteString(" NOT")
+	q.shardKeyUnknown = true
+	return q
+}
+
+func (q *TenantOrderQuery) IdEqual(v int64) *TenantOrderQuery {
+	q.where.WriteString(" id=?")
+	q.whereParams = append(q.whereParams, v)
+	q.addShardKey("id", v)
+	return q
+}
+
+func (q *TenantOrderQuery) IdNotEqual(v int64) *TenantOrderQuery {
+	q.where.WriteString(" id<>?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) IdLess(v int64) *TenantOrderQuery {
+	q.where.WriteString(" id<?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) IdLessEqual(v int64) *TenantOrderQuery {
+	q.where.WriteString(" id<=?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) IdGreater(v int64) *TenantOrderQuery {
+	q.where.WriteString(" id>?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) IdGreaterEqual(v int64) *TenantOrderQuery {
+	q.where.WriteString(" id>=?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) IdIn(items []int64) *TenantOrderQuery {
+	q.where.WriteString(" id IN(")
+	q.where.WriteString(wrap.RepeatWithSeparator("?", len(items), ","))
+	q.where.WriteString(")")
+	for _, v := range items {
+		q.whereParams = append(q.whereParams, v)
+		q.addShardKey("id", v)
+	}
+	return q
+}
+
+func (q *TenantOrderQuery) TenantIdEqual(v string) *TenantOrderQuery {
+	q.where.WriteString(" tenant_id=?")
+	q.whereParams = append(q.whereParams, v)
+	q.addShardKey("tenant_id", v)
+	return q
+}
+
+func (q *TenantOrderQuery) TenantIdNotEqual(v string) *TenantOrderQuery {
+	q.where.WriteString(" tenant_id<>?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) TenantIdIn(items []string) *TenantOrderQuery {
+	q.where.WriteString(" tenant_id IN(")
+	q.where.WriteString(wrap.RepeatWithSeparator("?", len(items), ","))
+	q.where.WriteString(")")
+	for _, v := range items {
+		q.whereParams = append(q.whereParams, v)
+		q.addShardKey("tenant_id", v)
+	}
+	return q
+}
+
+func (q *TenantOrderQuery) OrderNoEqual(v string) *TenantOrderQuery {
+	q.where.WriteString(" order_no=?")
+	q.whereParams = append(q.whereParams, v)
+	q.addShardKey("order_no", v)
+	return q
+}
+
+func (q *TenantOrderQuery) OrderNoNotEqual(v string) *TenantOrderQuery {
+	q.where.WriteString(" order_no<>?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) OrderNoIn(items []string) *TenantOrderQuery {
+	q.where.WriteString(" order_no IN(")
+	q.where.WriteString(wrap.RepeatWithSeparator("?", len(items), ","))
+	q.where.WriteString(")")
+	for _, v := range items {
+		q.whereParams = append(q.whereParams, v)
+		q.addShardKey("order_no", v)
+	}
+	return q
+}
+
+func (q *TenantOrderQuery) AmountEqual(v int64) *TenantOrderQuery {
+	q.where.WriteString(" amount=?")
+	q.whereParams = append(q.whereParams, v)
+	q.addShardKey("amount", v)
+	return q
+}
+
+func (q *TenantOrderQuery) AmountNotEqual(v int64) *TenantOrderQuery {
+	q.where.WriteString(" amount<>?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) AmountLess(v int64) *TenantOrderQuery {
+	q.where.WriteString(" amount<?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) AmountLessEqual(v int64) *TenantOrderQuery {
+	q.where.WriteString(" amount<=?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) AmountGreater(v int64) *TenantOrderQuery {
+	q.where.WriteString(" amount>?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) AmountGreaterEqual(v int64) *TenantOrderQuery {
+	q.where.WriteString(" amount>=?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) AmountIn(items []int64) *TenantOrderQuery {
+	q.where.WriteString(" amount IN(")
+	q.where.WriteString(wrap.RepeatWithSeparator("?", len(items), ","))
+	q.where.WriteString(")")
+	for _, v := range items {
+		q.whereParams = append(q.whereParams, v)
+		q.addShardKey("amount", v)
+	}
+	return q
+}
+
+func (q *TenantOrderQuery) RemarkEqual(v string) *TenantOrderQuery {
+	q.where.WriteString(" remark=?")
+	q.whereParams = append(q.whereParams, v)
+	q.addShardKey("remark", v)
+	return q
+}
+
+func (q *TenantOrderQuery) RemarkNotEqual(v string) *TenantOrderQuery {
+	q.where.WriteString(" remark<>?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) RemarkIsNull() *TenantOrderQuery {
+	q.where.WriteString(" remark IS NULL")
+	return q
+}
+
+func (q *TenantOrderQuery) RemarkIsNotNull() *TenantOrderQuery {
+	q.where.WriteString(" remark IS NOT NULL")
+	return q
+}
+
+func (q *TenantOrderQuery) RemarkIn(items []string) *TenantOrderQuery {
+	q.where.WriteString(" remark IN(")
+	q.where.WriteString(wrap.RepeatWithSeparator("?", len(items), ","))
+	q.where.WriteString(")")
+	for _, v := range items {
+		q.whereParams = append(q.whereParams, v)
+		q.addShardKey("remark", v)
+	}
+	return q
+}
+
+func (q *TenantOrderQuery) CreateTimeEqual(v time.Time) *TenantOrderQuery {
+	q.where.WriteString(" create_time=?")
+	q.whereParams = append(q.whereParams, v)
+	q.addShardKey("create_time", v)
+	return q
+}
+
+func (q *TenantOrderQuery) CreateTimeNotEqual(v time.Time) *TenantOrderQuery {
+	q.where.WriteString(" create_time<>?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) CreateTimeLess(v time.Time) *TenantOrderQuery {
+	q.where.WriteString(" create_time<?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) CreateTimeLessEqual(v time.Time) *TenantOrderQuery {
+	q.where.WriteString(" create_time<=?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) CreateTimeGreater(v time.Time) *TenantOrderQuery {
+	q.where.WriteString(" create_time>?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) CreateTimeGreaterEqual(v time.Time) *TenantOrderQuery {
+	q.where.WriteString(" create_time>=?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) UpdateTimeEqual(v time.Time) *TenantOrderQuery {
+	q.where.WriteString(" update_time=?")
+	q.whereParams = append(q.whereParams, v)
+	q.addShardKey("update_time", v)
+	return q
+}
+
+func (q *TenantOrderQuery) UpdateTimeNotEqual(v time.Time) *TenantOrderQuery {
+	q.where.WriteString(" update_time<>?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) UpdateTimeLess(v time.Time) *TenantOrderQuery {
+	q.where.WriteString(" update_time<?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) UpdateTimeLessEqual(v time.Time) *TenantOrderQuery {
+	q.where.WriteString(" update_time<=?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) UpdateTimeGreater(v time.Time) *TenantOrderQuery {
+	q.where.WriteString(" update_time>?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) UpdateTimeGreaterEqual(v time.Time) *TenantOrderQuery {
+	q.where.WriteString(" update_time>=?")
+	q.whereParams = append(q.whereParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) GroupByTenantId(asc bool) *TenantOrderQuery {
+	q.groupByFields = append(q.groupByFields, "tenant_id")
+	q.groupByOrders = append(q.groupByOrders, asc)
+	return q
+}
+
+func (q *TenantOrderQuery) GroupByOrderNo(asc bool) *TenantOrderQuery {
+	q.groupByFields = append(q.groupByFields, "order_no")
+	q.groupByOrders = append(q.groupByOrders, asc)
+	return q
+}
+
+func (q *TenantOrderQuery) GroupByAmount(asc bool) *TenantOrderQuery {
+	q.groupByFields = append(q.groupByFields, "amount")
+	q.groupByOrders = append(q.groupByOrders, asc)
+	return q
+}
+
+func (q *TenantOrderQuery) GroupByRemark(asc bool) *TenantOrderQuery {
+	q.groupByFields = append(q.groupByFields, "remark")
+	q.groupByOrders = append(q.groupByOrders, asc)
+	return q
+}
+
+func (q *TenantOrderQuery) OrderById(asc bool) *TenantOrderQuery {
+	q.orderByFields = append(q.orderByFields, "id")
+	q.orderByOrders = append(q.orderByOrders, asc)
+	return q
+}
+
+func (q *TenantOrderQuery) OrderByTenantId(asc bool) *TenantOrderQuery {
+	q.orderByFields = append(q.orderByFields, "tenant_id")
+	q.orderByOrders = append(q.orderByOrders, asc)
+	return q
+}
+
+func (q *TenantOrderQuery) OrderByOrderNo(asc bool) *TenantOrderQuery {
+	q.orderByFields = append(q.orderByFields, "order_no")
+	q.orderByOrders = append(q.orderByOrders, asc)
+	return q
+}
+
+func (q *TenantOrderQuery) OrderByAmount(asc bool) *TenantOrderQuery {
+	q.orderByFields = append(q.orderByFields, "amount")
+	q.orderByOrders = append(q.orderByOrders, asc)
+	return q
+}
+
+func (q *TenantOrderQuery) OrderByRemark(asc bool) *TenantOrderQuery {
+	q.orderByFields = append(q.orderByFields, "remark")
+	q.orderByOrders = append(q.orderByOrders, asc)
+	return q
+}
+
+func (q *TenantOrderQuery) OrderByCreateTime(asc bool) *TenantOrderQuery {
+	q.orderByFields = append(q.orderByFields, "create_time")
+	q.orderByOrders = append(q.orderByOrders, asc)
+	return q
+}
+
+func (q *TenantOrderQuery) OrderByUpdateTime(asc bool) *TenantOrderQuery {
+	q.orderByFields = append(q.orderByFields, "update_time")
+	q.orderByOrders = append(q.orderByOrders, asc)
+	return q
+}
+
+func (q *TenantOrderQuery) OrderByGroupCount(asc bool) *TenantOrderQuery {
+	q.orderByFields = append(q.orderByFields, "count(*)")
+	q.orderByOrders = append(q.orderByOrders, asc)
+	return q
+}
+
+func (q *TenantOrderQuery) Limit(startIncluded int64, count int64) *TenantOrderQuery {
+	q.hasLimit = true
+	q.limitStartIncluded = startIncluded
+	q.limitCount = count
+	return q
+}
+
+func (q *TenantOrderQuery) AllShards() *TenantOrderQuery {
+	q.allShards = true
+	return q
+}
+
+func (q *TenantOrderQuery) ForUpdate() *TenantOrderQuery {
+	q.forUpdate = true
+	return q
+}
+
+func (q *TenantOrderQuery) ForShare() *TenantOrderQuery {
+	q.forShare = true
+	return q
+}
+
+func (q *TenantOrderQuery) Atomic() *TenantOrderQuery {
+	q.atomic = true
+	return q
+}
+
+func (q *TenantOrderQuery) SetTenantId(v string) *TenantOrderQuery {
+	q.updateFields = append(q.updateFields, "tenant_id")
+	q.updateParams = append(q.updateParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) SetOrderNo(v string) *TenantOrderQuery {
+	q.updateFields = append(q.updateFields, "order_no")
+	q.updateParams = append(q.updateParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) SetAmount(v int64) *TenantOrderQuery {
+	q.updateFields = append(q.updateFields, "amount")
+	q.updateParams = append(q.updateParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) SetRemark(v string) *TenantOrderQuery {
+	q.updateFields = append(q.updateFields, "remark")
+	q.updateParams = append(q.updateParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) DuplicatedUpdateAmount() *TenantOrderQuery {
+	q.duplicatedUpdateFields = append(q.duplicatedUpdateFields, "amount=VALUES(amount)")
+	return q
+}
+
+func (q *TenantOrderQuery) DuplicatedIncrementAmount() *TenantOrderQuery {
+	q.duplicatedUpdateFields = append(q.duplicatedUpdateFields, "amount=amount+VALUES(amount)")
+	return q
+}
+
+func (q *TenantOrderQuery) DuplicatedKeepAmount() *TenantOrderQuery {
+	q.duplicatedUpdateFields = append(q.duplicatedUpdateFields, "amount=amount")
+	return q
+}
+
+func (q *TenantOrderQuery) DuplicatedSetAmount(v int64) *TenantOrderQuery {
+	q.duplicatedUpdateFields = append(q.duplicatedUpdateFields, "amount=?")
+	q.duplicatedUpdateParams = append(q.duplicatedUpdateParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) DuplicatedUpdateRemark() *TenantOrderQuery {
+	q.duplicatedUpdateFields = append(q.duplicatedUpdateFields, "remark=VALUES(remark)")
+	return q
+}
+
+func (q *TenantOrderQuery) DuplicatedKeepRemark() *TenantOrderQuery {
+	q.duplicatedUpdateFields = append(q.duplicatedUpdateFields, "remark=remark")
+	return q
+}
+
+func (q *TenantOrderQuery) DuplicatedSetRemark(v string) *TenantOrderQuery {
+	q.duplicatedUpdateFields = append(q.duplicatedUpdateFields, "remark=?")
+	q.duplicatedUpdateParams = append(q.duplicatedUpdateParams, v)
+	return q
+}
+
+func (q *TenantOrderQuery) GetId() *TenantOrderQuery {
+	q.getFields = append(q.getFields, "id")
+	return q
+}
+
+func (q *TenantOrderQuery) GetTenantId() *TenantOrderQuery {
+	q.getFields = append(q.getFields, "tenant_id")
+	return q
+}
+
+func (q *TenantOrderQuery) GetOrderNo() *TenantOrderQuery {
+	q.getFields = append(q.getFields, "order_no")
+	return q
+}
+
+func (q *TenantOrderQuery) GetAmount() *TenantOrderQuery {
+	q.getFields = append(q.getFields, "amount")
+	return q
+}
+
+func (q *TenantOrderQuery) GetRemark() *TenantOrderQuery {
+	q.getFields = append(q.getFields, "remark")
+	return q
+}
+
+func (q *TenantOrderQuery) GetCreateTime() *TenantOrderQuery {
+	q.getFields = append(q.getFields, "create_time")
+	return q
+}
+
+func (q *TenantOrderQuery) GetUpdateTime() *TenantOrderQuery {
+	q.getFields = append(q.getFields, "update_time")
+	return q
+}
+
+func (q *TenantOrderQuery) Select(ctx context.Context, tx *wrap.Tx) (e *TenantOrder, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.Select")
+	defer func() {
+		span.End(err)
+	}()
+
+	if !q.hasLimit {
+		q.limitCount = 1
+		q.hasLimit = true
+	}
+
+	shards, err := q.fanOutShards(ctx, tx, q.dao.db.DB)
+	if err != nil {
+		return nil, err
+	}
+	if len(shards) > 1 {
+		list, err := q.SelectList(ctx, tx)
+		if err != nil || len(list) == 0 {
+			return nil, err
+		}
+		return list[0], nil
+	}
+	db := shards[0]
+
+	queryString, params := q.buildSelectQuery()
+	query := bytes.NewBufferString("")
+	if len(q.getFields) == 0 {
+		query.WriteString("SELECT id,tenant_id,order_no,amount,remark,create_time,update_time FROM tenant_order ")
+	} else {
+		query.WriteString("SELECT ")
+		query.WriteString(strings.Join(q.getFields, ","))
+		query.WriteString(" FROM tenant_order ")
+	}
+	query.WriteString(queryString)
+	e = &TenantOrder{}
+	row := db.Executor(ctx, tx).QueryRow(ctx, query.String(), params...)
+	err = row.Scan(&e.Id, &e.TenantId, &e.OrderNo, &e.Amount, &e.Remark, &e.CreateTime, &e.UpdateTime)
+	if errors.Is(err, wrap.ErrNoRows) {
+		return nil, nil
+	}
+
+	return e, err
+}
+
+func (q *TenantOrderQuery) SelectList(ctx context.Context, tx *wrap.Tx) (list []*TenantOrder, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.SelectList")
+	defer func() {
+		span.End(err)
+	}()
+
+	shards, err := q.fanOutShards(ctx, tx, q.dao.db.DB)
+	if err != nil {
+		return nil, err
+	}
+	limitStart, limitCount := q.limitStartIncluded, q.limitCount
+	if len(shards) > 1 && q.hasLimit {
+		q.limitStartIncluded, q.limitCount = 0, limitStart+limitCount
+	}
+
+	queryString, params := q.buildSelectQuery()
+	q.limitStartIncluded, q.limitCount = limitStart, limitCount
+	query := bytes.NewBufferString("")
+	if len(q.getFields) == 0 {
+		query.WriteString("SELECT id,tenant_id,order_no,amount,remark,create_time,update_time FROM tenant_order ")
+	} else {
+		query.WriteString("SELECT ")
+		query.WriteString(strings.Join(q.getFields, ","))
+		query.WriteString(" FROM tenant_order ")
+	}
+	query.WriteString(queryString)
+	results := make([][]*TenantOrder, len(shards))
+	err = wrap.FanOut(ctx, shards, func(ctx context.Context, i int, db *wrap.DB) error {
+		rows, err := db.Executor(ctx, tx).Query(ctx, query.String(), params...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			e := TenantOrder{}
+			err = rows.Scan(&e.Id, &e.TenantId, &e.OrderNo, &e.Amount, &e.Remark, &e.CreateTime, &e.UpdateTime)
+			if err != nil {
+				return err
+			}
+			results[i] = append(results[i], &e)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(shards) == 1 {
+		return results[0], nil
+	}
+
+	for _, r := range results {
+		list = append(list, r...)
+	}
+	if len(q.orderByFields) > 0 {
+		sort.SliceStable(list, func(i, j int) bool {
+			return q.less(list[i], list[j])
+		})
+	}
+	if q.hasLimit {
+		if limitStart >= int64(len(list)) {
+			return nil, nil
+		}
+		if limitStart+limitCount < int64(len(list)) {
+			list = list[:limitStart+limitCount]
+		}
+		list = list[limitStart:]
+	}
+
+	return list, nil
+}
+
+func (q *TenantOrderQuery) less(a *TenantOrder, b *TenantOrder) bool {
+	for i, field := range q.orderByFields {
+		va, _ := q.dao.fieldValue(a, field)
+		vb, _ := q.dao.fieldValue(b, field)
+		if c := wrap.CompareValues(va, vb); c != 0 {
+			return (c < 0) == q.orderByOrders[i]
+		}
+	}
+
+	return false
+}
+
+func (q *TenantOrderQuery) SelectCount(ctx context.Context, tx *wrap.Tx) (count int64, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.SelectCount")
+	defer func() {
+		span.End(err)
+	}()
+
+	shards, err := q.fanOutShards(ctx, tx, q.dao.db.DB)
+	if err != nil {
+		return 0, err
+	}
+
+	queryString, params := q.buildSelectQuery()
+	query := bytes.NewBufferString("")
+	query.WriteString("SELECT COUNT(*) FROM tenant_order ")
+	query.WriteString(queryString)
+	counts := make([]int64, len(shards))
+	err = wrap.FanOut(ctx, shards, func(ctx context.Context, i int, db *wrap.DB) error {
+		return db.Executor(ctx, tx).QueryRow(ctx, query.String(), params...).Scan(&counts[i])
+	})
+	for _, n := range counts {
+		count += n
+	}
+
+	return count, err
+}
+
+func (q *TenantOrderQuery) SelectGroupBy(ctx context.Context, tx *wrap.Tx, withCount bool) (rows *wrap.Rows, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.SelectGroupBy")
+	defer func() {
+		span.End(err)
+	}()
+
+	db, err := q.shard(q.dao.db.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	queryString, params := q.buildSelectQuery()
+	query := bytes.NewBufferString("")
+	query.WriteString("SELECT ")
+	query.WriteString(strings.Join(q.groupByFields, ","))
+	if withCount {
+		query.WriteString(",MachineListCount(*) ")
+	}
+	query.WriteString(" FROM tenant_order ")
+	query.WriteString(queryString)
+
+	return db.Executor(ctx, tx).Query(ctx, query.String(), params...)
+}
+
+func (q *TenantOrderQuery) SelectRow(ctx context.Context, tx *wrap.Tx) (row *wrap.Row) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.SelectRow")
+	defer func() {
+		span.End(row.Err())
+	}()
+
+	db, err := q.shard(q.dao.db.DB)
+	if err != nil {
+		return wrap.NewErrorRow(err)
+	}
+
+	if !q.hasLimit {
+		q.limitCount = 1
+		q.hasLimit = true
+	}
+
+	queryString, params := q.buildSelectQuery()
+	query := bytes.NewBufferString("")
+	query.WriteString("SELECT ")
+	query.WriteString(strings.Join(q.getFields, ","))
+	query.WriteString(" FROM tenant_order ")
+	query.WriteString(queryString)
+	return db.Executor(ctx, tx).QueryRow(ctx, query.String(), params...)
+}
+
+func (q *TenantOrderQuery) SelectRows(ctx context.Context, tx *wrap.Tx) (rows *wrap.Rows, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.SelectRows")
+	defer func() {
+		span.End(err)
+	}()
+
+	db, err := q.shard(q.dao.db.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	queryString, params := q.buildSelectQuery()
+	query := bytes.NewBufferString("")
+	query.WriteString("SELECT ")
+	query.WriteString(strings.Join(q.getFields, ","))
+	query.WriteString(" FROM tenant_order ")
+	query.WriteString(queryString)
+	return db.Executor(ctx, tx).Query(ctx, query.String(), params...)
+}
+
+func (q *TenantOrderQuery) Insert(ctx context.Context, tx *wrap.Tx, e *TenantOrder) (result *wrap.Result, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.Insert")
+	defer func() {
+		span.End(err)
+	}()
+
+	db, err := q.dao.entityShard(e)
+	if err != nil {
+		return nil, err
+	}
+
+	now := q.dao.db.Now()
+	createTime := now.Truncate(time.Second)
+	updateTime := now.Truncate(time.Second)
+	query := bytes.NewBufferString("")
+	query.WriteString("INSERT INTO tenant_order (tenant_id,order_no,amount,remark,create_time,update_time) VALUES (?,?,?,?,?,?)")
+	params := []interface{}{e.TenantId, e.OrderNo, e.Amount, e.Remark, createTime, updateTime}
+	result, err = db.Executor(ctx, tx).Exec(ctx, query.String(), params...)
+	if err != nil {
+		return nil, err
+	}
+
+	e.CreateTime = createTime
+	e.UpdateTime = updateTime
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	e.Id = int64(id)
+
+	return result, nil
+}
+
+// BatchInsert 自增ID不连续(innodb_autoinc_lock_mode=2)且不支持RETURNING时，行已写入但不写回ID，此时result.IdsAssigned()为false
+func (q *TenantOrderQuery) BatchInsert(ctx context.Context, tx *wrap.Tx, list []*TenantOrder) (result *wrap.Result, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.BatchInsert")
+	defer func() {
+		span.End(err)
+	}()
+
+	db, err := q.dao.entityShard(list...)
+	if err != nil {
+		return nil, err
+	}
+
+	now := q.dao.db.Now()
+	createTime := now.Truncate(time.Second)
+	updateTime := now.Truncate(time.Second)
+	params := make([]interface{}, len(list)*6)
+	offset := 0
+	for _, e := range list {
+		params[offset+0] = e.TenantId
+		params[offset+1] = e.OrderNo
+		params[offset+2] = e.Amount
+		params[offset+3] = e.Remark
+		params[offset+4] = createTime
+		params[offset+5] = updateTime
+		offset += 6
+	}
+
+	batches, err := db.SplitBatch(ctx, tx, params, 6, 0, 91)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ExecBatch(ctx, tx, q.atomic, batches, func(tx *wrap.Tx, b *wrap.Batch) (result *wrap.Result, err error) {
+		list := list[b.Start:b.End]
+		params := params[b.Start*6 : b.End*6 : b.End*6]
+		query := bytes.NewBufferString("")
+		query.WriteString("INSERT INTO tenant_order (tenant_id,order_no,amount,remark,create_time,update_time) VALUES ")
+		query.WriteString(wrap.RepeatWithSeparator("(?,?,?,?,?,?)", len(list), ","))
+
+		autoIncrement, err := db.AutoIncrement(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		if autoIncrement.Returning {
+			query.WriteString(" RETURNING id")
+			rows, err := db.Executor(ctx, tx).Query(ctx, query.String(), params...)
+			if err != nil {
+				return nil, err
+			}
+			defer rows.Close()
+			count := 0
+			for ; count < len(list) && rows.Next(); count++ {
+				err = rows.Scan(&list[count].Id)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if err = rows.Err(); err != nil {
+				return nil, err
+			}
+			var lastInsertId int64
+			if count > 0 {
+				lastInsertId = int64(list[0].Id)
+			}
+			result = wrap.NewResult(lastInsertId, int64(count))
+		} else {
+			result, err = db.Executor(ctx, tx).Exec(ctx, query.String(), params...)
+			if err != nil {
+				return nil, err
+			}
+
+			ids, err := db.InsertIds(ctx, tx, result, len(list))
+			if errors.Is(err, wrap.ErrIdsNotContiguous) {
+				result.MarkIdsUnassigned()
+			} else if err != nil {
+				return nil, err
+			}
+			for i, id := range ids {
+				list[i].Id = int64(id)
+			}
+		}
+
+		for _, e := range list {
+			e.CreateTime = createTime
+			e.UpdateTime = updateTime
+		}
+
+		return result, nil
+	})
+}
+
+func (q *TenantOrderQuery) InsertIgnore(ctx context.Context, tx *wrap.Tx, e *TenantOrder) (result *wrap.Result, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.InsertIgnore")
+	defer func() {
+		span.End(err)
+	}()
+
+	db, err := q.dao.entityShard(e)
+	if err != nil {
+		return nil, err
+	}
+
+	now := q.dao.db.Now()
+	createTime := now.Truncate(time.Second)
+	updateTime := now.Truncate(time.Second)
+	query := bytes.NewBufferString("")
+	query.WriteString("INSERT IGNORE INTO tenant_order (tenant_id,order_no,amount,remark,create_time,update_time) VALUES (?,?,?,?,?,?)")
+	params := []interface{}{e.TenantId, e.OrderNo, e.Amount, e.Remark, createTime, updateTime}
+	result, err = db.Executor(ctx, tx).Exec(ctx, query.String(), params...)
+	if err != nil {
+		return nil, err
+	}
+
+	if n, _ := result.RowsAffected(); n == 1 {
+		e.CreateTime = createTime
+		e.UpdateTime = updateTime
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		e.Id = int64(id)
+	}
+
+	return result, nil
+}
+
+func (q *TenantOrderQuery) BatchInsertIgnore(ctx context.Context, tx *wrap.Tx, list []*TenantOrder) (result *wrap.Result, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.BatchInsertIgnore")
+	defer func() {
+		span.End(err)
+	}()
+
+	db, err := q.dao.entityShard(list...)
+	if err != nil {
+		return nil, err
+	}
+
+	now := q.dao.db.Now()
+	createTime := now.Truncate(time.Second)
+	updateTime := now.Truncate(time.Second)
+	params := make([]interface{}, len(list)*6)
+	offset := 0
+	for _, e := range list {
+		params[offset+0] = e.TenantId
+		params[offset+1] = e.OrderNo
+		params[offset+2] = e.Amount
+		params[offset+3] = e.Remark
+		params[offset+4] = createTime
+		params[offset+5] = updateTime
+		offset += 6
+	}
+
+	batches, err := db.SplitBatch(ctx, tx, params, 6, 0, 98)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ExecBatch(ctx, tx, q.atomic, batches, func(tx *wrap.Tx, b *wrap.Batch) (result *wrap.Result, err error) {
+		list := list[b.Start:b.End]
+		params := params[b.Start*6 : b.End*6 : b.End*6]
+		query := bytes.NewBufferString("")
+		query.WriteString("INSERT IGNORE INTO tenant_order (tenant_id,order_no,amount,remark,create_time,update_time) VALUES ")
+		query.WriteString(wrap.RepeatWithSeparator("(?,?,?,?,?,?)", len(list), ","))
+
+		result, err = db.Executor(ctx, tx).Exec(ctx, query.String(), params...)
+		if err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	})
+}
+
+func (q *TenantOrderQuery) Replace(ctx context.Context, tx *wrap.Tx, e *TenantOrder) (result *wrap.Result, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.Replace")
+	defer func() {
+		span.End(err)
+	}()
+
+	db, err := q.dao.entityShard(e)
+	if err != nil {
+		return nil, err
+	}
+
+	now := q.dao.db.Now()
+	createTime := now.Truncate(time.Second)
+	updateTime := now.Truncate(time.Second)
+	query := bytes.NewBufferString("")
+	query.WriteString("REPLACE INTO tenant_order (tenant_id,order_no,amount,remark,create_time,update_time) VALUES (?,?,?,?,?,?)")
+	params := []interface{}{e.TenantId, e.OrderNo, e.Amount, e.Remark, createTime, updateTime}
+	result, err = db.Executor(ctx, tx).Exec(ctx, query.String(), params...)
+	if err != nil {
+		return nil, err
+	}
+
+	e.CreateTime = createTime
+	e.UpdateTime = updateTime
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	e.Id = int64(id)
+
+	return result, nil
+}
+
+func (q *TenantOrderQuery) BatchReplace(ctx context.Context, tx *wrap.Tx, list []*TenantOrder) (result *wrap.Result, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.BatchReplace")
+	defer func() {
+		span.End(err)
+	}()
+
+	db, err := q.dao.entityShard(list...)
+	if err != nil {
+		return nil, err
+	}
+
+	now := q.dao.db.Now()
+	createTime := now.Truncate(time.Second)
+	updateTime := now.Truncate(time.Second)
+	params := make([]interface{}, len(list)*6)
+	offset := 0
+	for _, e := range list {
+		params[offset+0] = e.TenantId
+		params[offset+1] = e.OrderNo
+		params[offset+2] = e.Amount
+		params[offset+3] = e.Remark
+		params[offset+4] = createTime
+		params[offset+5] = updateTime
+		offset += 6
+	}
+
+	batches, err := db.SplitBatch(ctx, tx, params, 6, 0, 92)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ExecBatch(ctx, tx, q.atomic, batches, func(tx *wrap.Tx, b *wrap.Batch) (result *wrap.Result, err error) {
+		list := list[b.Start:b.End]
+		params := params[b.Start*6 : b.End*6 : b.End*6]
+		query := bytes.NewBufferString("")
+		query.WriteString("REPLACE INTO tenant_order (tenant_id,order_no,amount,remark,create_time,update_time) VALUES ")
+		query.WriteString(wrap.RepeatWithSeparator("(?,?,?,?,?,?)", len(list), ","))
+
+		result, err = db.Executor(ctx, tx).Exec(ctx, query.String(), params...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range list {
+			e.CreateTime = createTime
+			e.UpdateTime = updateTime
+		}
+
+		return result, nil
+	})
+}
+
+func (q *TenantOrderQuery) InsertOnDuplicatedKeyUpdate(ctx context.Context, tx *wrap.Tx, e *TenantOrder) (result *wrap.Result, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.InsertOnDuplicatedKeyUpdate")
+	defer func() {
+		span.End(err)
+	}()
+
+	db, err := q.dao.entityShard(e)
+	if err != nil {
+		return nil, err
+	}
+
+	now := q.dao.db.Now()
+	createTime := now.Truncate(time.Second)
+	updateTime := now.Truncate(time.Second)
+	query := bytes.NewBufferString("")
+	query.WriteString("INSERT INTO tenant_order (tenant_id,order_no,amount,remark,create_time,update_time) VALUES (?,?,?,?,?,?)")
+	params := []interface{}{e.TenantId, e.OrderNo, e.Amount, e.Remark, createTime, updateTime}
+	query.WriteString(" ON DUPLICATE KEY UPDATE ")
+	query.WriteString(strings.Join(append(append([]string(nil), q.duplicatedUpdateFields...), "id=LAST_INSERT_ID(id)", "update_time=VALUES(update_time)"), ","))
+	params = append(params, q.duplicatedUpdateParams...)
+	result, err = db.Executor(ctx, tx).Exec(ctx, query.String(), params...)
+	if err != nil {
+		return nil, err
+	}
+
+	e.UpdateTime = updateTime
+	if n, _ := result.RowsAffected(); n == 1 {
+		e.CreateTime = createTime
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if id != 0 {
+		e.Id = int64(id)
+	}
+
+	return result, nil
+}
+
+func (q *TenantOrderQuery) BatchInsertOnDuplicatedKeyUpdate(ctx context.Context, tx *wrap.Tx, list []*TenantOrder) (result *wrap.Result, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.BatchInsertOnDuplicatedKeyUpdate")
+	defer func() {
+		span.End(err)
+	}()
+
+	db, err := q.dao.entityShard(list...)
+	if err != nil {
+		return nil, err
+	}
+
+	now := q.dao.db.Now()
+	createTime := now.Truncate(time.Second)
+	updateTime := now.Truncate(time.Second)
+	params := make([]interface{}, len(list)*6)
+	offset := 0
+	for _, e := range list {
+		params[offset+0] = e.TenantId
+		params[offset+1] = e.OrderNo
+		params[offset+2] = e.Amount
+		params[offset+3] = e.Remark
+		params[offset+4] = createTime
+		params[offset+5] = updateTime
+		offset += 6
+	}
+
+	batches, err := db.SplitBatch(ctx, tx, params, 6, len(q.duplicatedUpdateParams), 91)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ExecBatch(ctx, tx, q.atomic, batches, func(tx *wrap.Tx, b *wrap.Batch) (result *wrap.Result, err error) {
+		list := list[b.Start:b.End]
+		params := params[b.Start*6 : b.End*6 : b.End*6]
+		query := bytes.NewBufferString("")
+		query.WriteString("INSERT INTO tenant_order (tenant_id,order_no,amount,remark,create_time,update_time) VALUES ")
+		query.WriteString(wrap.RepeatWithSeparator("(?,?,?,?,?,?)", len(list), ","))
+		query.WriteString(" ON DUPLICATE KEY UPDATE ")
+		query.WriteString(strings.Join(append(append([]string(nil), q.duplicatedUpdateFields...), "update_time=VALUES(update_time)"), ","))
+		params = append(params, q.duplicatedUpdateParams...)
+
+		result, err = db.Executor(ctx, tx).Exec(ctx, query.String(), params...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range list {
+			e.UpdateTime = updateTime
+		}
+
+		return result, nil
+	})
+}
+
+func (q *TenantOrderQuery) Update(ctx context.Context, tx *wrap.Tx) (result *wrap.Result, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.Update")
+	defer func() {
+		span.End(err)
+	}()
+
+	db, err := q.shard(q.dao.db.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	now := q.dao.db.Now()
+	updateTime := now.Truncate(time.Second)
+	query := bytes.NewBufferString("")
+	var params []interface{}
+	params = append(params, q.updateParams...)
+	query.WriteString("UPDATE tenant_order SET ")
+	updateItems := make([]string, len(q.updateFields))
+	for i, v := range q.updateFields {
+		updateItems[i] = v + "=?"
+	}
+	updateItems = append(updateItems, "update_time=?")
+	params = append(params, updateTime)
+	query.WriteString(strings.Join(updateItems, ","))
+	where, whereParams := q.buildWhere()
+	if where != "" {
+		query.WriteString(" WHERE ")
+		query.WriteString(where)
+		params = append(params, whereParams...)
+	}
+
+	return db.Executor(ctx, tx).Exec(ctx, query.String(), params...)
+}
+
+const (
+	TenantOrderFieldTenantId = "tenant_id"
+	TenantOrderFieldOrderNo  = "order_no"
+	TenantOrderFieldAmount   = "amount"
+	TenantOrderFieldRemark   = "remark"
+)
+
+func (q *TenantOrderQuery) batchUpdateValue(e *TenantOrder, field string) (v interface{}, err error) {
+	switch field {
+	case "tenant_id":
+		return e.TenantId, nil
+	case "order_no":
+		return e.OrderNo, nil
+	case "amount":
+		return e.Amount, nil
+	case "remark":
+		return e.Remark, nil
+	}
+
+	return nil, fmt.Errorf("tenant_order batch update unknown field %s", field)
+}
+
+func (q *TenantOrderQuery) BatchUpdate(ctx context.Context, tx *wrap.Tx, list []*TenantOrder, fields ...string) (result *wrap.Result, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.BatchUpdate")
+	defer func() {
+		span.End(err)
+	}()
+
+	db, err := q.dao.entityShard(list...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		fields = []string{"tenant_id", "order_no", "amount", "remark"}
+	}
+	now := q.dao.db.Now()
+	updateTime := now.Truncate(time.Second)
+
+	rowParams := 1 + 2*len(fields)
+	values := make([]interface{}, len(list)*rowParams)
+	for i, e := range list {
+		offset := i * rowParams
+		values[offset] = e.Id
+		for j, field := range fields {
+			values[offset+1+2*j] = e.Id
+			values[offset+2+2*j], err = q.batchUpdateValue(e, field)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	batches, err := db.SplitBatch(ctx, tx, values, rowParams, 1, len("UPDATE tenant_order SET ")+32*len(fields))
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ExecBatch(ctx, tx, q.atomic, batches, func(tx *wrap.Tx, b *wrap.Batch) (result *wrap.Result, err error) {
+		list := list[b.Start:b.End]
+		values := values[b.Start*rowParams : b.End*rowParams]
+		params := make([]interface{}, 0, len(values)+1)
+		query := bytes.NewBufferString("")
+		query.WriteString("UPDATE tenant_order SET ")
+		for j, field := range fields {
+			if j > 0 {
+				query.WriteString(",")
+			}
+			query.WriteString(field)
+			query.WriteString("=CASE id")
+			for i := range list {
+				query.WriteString(" WHEN ? THEN ?")
+				params = append(params, values[i*rowParams+1+2*j], values[i*rowParams+2+2*j])
+			}
+			query.WriteString(" END")
+		}
+		query.WriteString(",update_time=?")
+		params = append(params, updateTime)
+		query.WriteString(" WHERE id IN (")
+		query.WriteString(wrap.RepeatWithSeparator("?", len(list), ","))
+		query.WriteString(")")
+		for i := range list {
+			params = append(params, values[i*rowParams])
+		}
+		if q.softDeleteWhere != "" {
+			query.WriteString(" AND ")
+			query.WriteString(q.softDeleteWhere)
+		}
+
+		result, err = db.Executor(ctx, tx).Exec(ctx, query.String(), params...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range list {
+			e.UpdateTime = updateTime
+		}
+
+		return result, nil
+	})
+}
+
+func (q *TenantOrderQuery) Delete(ctx context.Context, tx *wrap.Tx) (result *wrap.Result, err error) {
+	ctx = wrap.WithTable(ctx, q.tableName)
+	ctx, span := q.dao.db.StartSpan(ctx, "TenantOrderDao.Delete")
+	defer func() {
+		span.End(err)
+	}()
+
+	db, err := q.shard(q.dao.db.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "DELETE FROM tenant_order WHERE " + q.where.String()
+	return db.Executor(ctx, tx).Exec(ctx, query, q.whereParams...)
+}
+
+type TenantOrderDao struct {
+	db       *DB
+	sharding *wrap.Sharding
+}
+
+func NewTenantOrderDao(db *DB) (t *TenantOrderDao, err error) {
+	t = &TenantOrderDao{}
+	t.db = db
+
+	return t, nil
+}
+
+func (dao *TenantOrderDao) Query() *TenantOrderQuery {
+	q := &TenantOrderQuery{}
+	q.dao = dao
+	q.tableName = "tenant_order"
+	q.where = bytes.NewBufferString("")
+	q.sharding = dao.sharding
+	return q
+}
+
+func (dao *TenantOrderDao) SetSharding(s *wrap.Sharding) error {
+	if s != nil {
+		if _, ok := dao.fieldValue(&TenantOrder{}, s.Column); !ok {
+			return fmt.Errorf("tenant_order sharding unknown column %s", s.Column)
+		}
+	}
+
+	dao.sharding = s
+	return nil
+}
+
+func (dao *TenantOrderDao) fieldValue(e *TenantOrder, field string) (v interface{}, ok bool) {
+	switch field {
+	case "id":
+		return e.Id, true
+	case "tenant_id":
+		return e.TenantId, true
+	case "order_no":
+		return e.OrderNo, true
+	case "amount":
+		return e.Amount, true
+	case "remark":
+		return e.Remark, true
+	case "create_time":
+		return e.CreateTime, true
+	case "update_time":
+		return e.UpdateTime, true
+	}
+
+	return nil, false
+}
+
+func (dao *TenantOrderDao) entityShard(list ...*TenantOrder) (*wrap.DB, error) {
+	if dao.sharding == nil || len(list) == 0 {
+		return dao.db.DB, nil
+	}
+
+	var db *wrap.DB
+	for _, e := range list {
+		v, _ := dao.fieldValue(e, dao.sharding.Column)
+		shard, err := dao.sharding.Shard(v)
+		if err != nil {
+			return nil, err
+		}
+		if db != nil && shard != db {
+			return nil, wrap.ErrCrossShard
+		}
+		db = shard
+	}
+
+	return db, nil
+}
+
+type DB struct {
+	*wrap.DB
+	TenantOrder *TenantOrderDao
+}
+
+func NewDB(opts ...wrap.Option) (d *DB, err error) {
+	d = &DB{}
+
+	connectionString := os.Getenv("DB")
+	if connectionString == "" {
+		return nil, fmt.Errorf("DB env nil")
+	}
+	connectionString += "/test_db?parseTime=true"
+	db, err := wrap.Open("mysql", connectionString, opts...)
+	if err != nil {
+		return nil, err
+	}
+	d.DB = db
+
+	err = d.Ping(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	d.TenantOrder, err = NewTenantOrderDao(d)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
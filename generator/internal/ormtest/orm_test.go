@@ -0,0 +1,194 @@
+package ormtest
+
+import (
+	"context"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/NeuronFramework/sql/wrap"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var mockDSNId atomic.Int64
+
+var testNow = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func newMockDB(t *testing.T) (*DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	dsn := fmt.Sprintf("ormtest_%d", mockDSNId.Add(1))
+	_, mock, err := sqlmock.NewWithDSN(dsn, sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := wrap.Open("sqlmock", dsn, wrap.WithClock(func() time.Time { return testNow }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		w.Close()
+	})
+	w.SetMaxAllowedPacket(4 << 20)
+
+	d := &DB{DB: w}
+	d.TenantOrder, err = NewTenantOrderDao(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return d, mock
+}
+
+func expectationsWereMet(t *testing.T, mocks ...sqlmock.Sqlmock) {
+	t.Helper()
+
+	for _, m := range mocks {
+		err := m.ExpectationsWereMet()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const insertQuery = "INSERT INTO tenant_order (tenant_id,order_no,amount,remark,create_time,update_time) VALUES "
+
+func TestInsertWriteBack(t *testing.T) {
+	d, mock := newMockDB(t)
+
+	mock.ExpectExec(insertQuery + "(?,?,?,?,?,?)").WillReturnResult(sqlmock.NewResult(7, 1))
+	e := &TenantOrder{TenantId: "a", OrderNo: "1", Amount: 10}
+	_, err := d.TenantOrder.Query().Insert(context.Background(), nil, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Id != 7 || !e.CreateTime.Equal(testNow) || !e.UpdateTime.Equal(testNow) {
+		t.Fatalf("e=%+v", e)
+	}
+
+	expectationsWereMet(t, mock)
+}
+
+func newOrders(n int) []*TenantOrder {
+	list := make([]*TenantOrder, n)
+	for i := range list {
+		list[i] = &TenantOrder{TenantId: "a", OrderNo: fmt.Sprint(i), Amount: int64(i)}
+	}
+	return list
+}
+
+func TestBatchInsertIds(t *testing.T) {
+	d, mock := newMockDB(t)
+	ctx := context.Background()
+
+	// 自增ID连续时按首个ID和auto_increment_increment推算
+	d.SetAutoIncrement(&wrap.AutoIncrement{LockMode: 1, Increment: 2})
+	mock.ExpectExec(insertQuery + "(?,?,?,?,?,?),(?,?,?,?,?,?),(?,?,?,?,?,?)").WillReturnResult(sqlmock.NewResult(10, 3))
+	list := newOrders(3)
+	result, err := d.TenantOrder.Query().BatchInsert(ctx, nil, list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IdsAssigned() {
+		t.Fatal("ids not assigned")
+	}
+	for i, e := range list {
+		if e.Id != int64(10+2*i) || !e.CreateTime.Equal(testNow) {
+			t.Fatalf("list[%d]=%+v", i, e)
+		}
+	}
+
+	// 支持RETURNING时取回ID
+	d.SetAutoIncrement(&wrap.AutoIncrement{LockMode: 2, Increment: 1, Returning: true})
+	mock.ExpectQuery(insertQuery + "(?,?,?,?,?,?),(?,?,?,?,?,?) RETURNING id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5).AddRow(9))
+	list = newOrders(2)
+	result, err = d.TenantOrder.Query().BatchInsert(ctx, nil, list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IdsAssigned() || list[0].Id != 5 || list[1].Id != 9 {
+		t.Fatalf("ids=%d,%d", list[0].Id, list[1].Id)
+	}
+
+	expectationsWereMet(t, mock)
+}
+
+// 自增ID不连续时行已写入，不返回错误，只是不写回ID
+func TestBatchInsertIdsNotContiguous(t *testing.T) {
+	d, mock := newMockDB(t)
+	ctx := context.Background()
+	d.SetAutoIncrement(&wrap.AutoIncrement{LockMode: 2, Increment: 1})
+
+	mock.ExpectExec(insertQuery + "(?,?,?,?,?,?),(?,?,?,?,?,?)").WillReturnResult(sqlmock.NewResult(10, 2))
+	list := newOrders(2)
+	result, err := d.TenantOrder.Query().BatchInsert(ctx, nil, list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IdsAssigned() || list[0].Id != 0 || list[1].Id != 0 {
+		t.Fatal("ids assigned")
+	}
+	if n, _ := result.RowsAffected(); n != 2 {
+		t.Fatalf("rowsAffected=%d", n)
+	}
+
+	// 多批在同一事务中提交后同样不返回错误
+	d.SetMaxAllowedPacket(200)
+	mock.ExpectBegin()
+	mock.ExpectExec(insertQuery + "(?,?,?,?,?,?)").WillReturnResult(sqlmock.NewResult(20, 1))
+	mock.ExpectExec(insertQuery + "(?,?,?,?,?,?)").WillReturnResult(sqlmock.NewResult(30, 1))
+	mock.ExpectCommit()
+	result, err = d.TenantOrder.Query().Atomic().BatchInsert(ctx, nil, newOrders(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IdsAssigned() {
+		t.Fatal("ids assigned")
+	}
+	if n, _ := result.RowsAffected(); n != 2 {
+		t.Fatalf("rowsAffected=%d", n)
+	}
+
+	expectationsWereMet(t, mock)
+}
+
+// 跨分片时各分片取前limitStart+limitCount条，合并排序后分页，同一查询可重复执行
+func TestSelectListCrossShard(t *testing.T) {
+	d, mock := newMockDB(t)
+	other, otherMock := newMockDB(t)
+	err := d.TenantOrder.SetSharding(&wrap.Sharding{
+		Column: "tenant_id",
+		Shards: []*wrap.DB{d.DB, other.DB},
+		Router: wrap.HashShardRouter(2),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const query = "SELECT id,tenant_id,order_no,amount,remark,create_time,update_time FROM tenant_order ORDER BY amount DESC LIMIT 0,3"
+	columns := []string{"id", "tenant_id", "order_no", "amount", "remark", "create_time", "update_time"}
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "a", "1", 50, nil, testNow, testNow).
+			AddRow(2, "a", "2", 30, nil, testNow, testNow).
+			AddRow(3, "a", "3", 10, nil, testNow, testNow))
+		otherMock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(4, "b", "4", 40, nil, testNow, testNow).
+			AddRow(5, "b", "5", 20, nil, testNow, testNow))
+	}
+
+	q := d.TenantOrder.Query().AllShards().OrderByAmount(false).Limit(1, 2)
+	for i := 0; i < 2; i++ {
+		list, err := q.SelectList(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 2 || list[0].Id != 4 || list[1].Id != 2 {
+			t.Fatalf("list=%+v", list)
+		}
+	}
+
+	expectationsWereMet(t, mock, otherMock)
+}
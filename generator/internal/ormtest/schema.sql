@@ -0,0 +1,16 @@
+-- 生成代码测试用表结构，修改后执行 go run ../../cmd -sql_file schema.sql -orm_file orm.go -package_name ormtest
+--
+-- Host: localhost    Database: test_db
+-- ------------------------------------------------------
+
+CREATE TABLE `tenant_order` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `tenant_id` varchar(64) NOT NULL,
+  `order_no` varchar(64) NOT NULL,
+  `amount` bigint(20) NOT NULL,
+  `remark` varchar(256) DEFAULT NULL,
+  `create_time` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `update_time` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_tenant_order` (`tenant_id`,`order_no`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
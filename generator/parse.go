@@ -105,6 +105,8 @@ func (g *Generator) parseColumnLine(line string) (c *Column, err error) {
 
 	c.GoType, c.GoTypeReal = goType(c.DbType, c.NotNull, c.Unsigned)
 	c.AutoIncrement = strings.Contains(line, "AUTO_INCREMENT")
+	c.DefaultCurrentTimestamp = strings.Contains(line, "DEFAULT CURRENT_TIMESTAMP")
+	c.OnUpdateCurrentTimestamp = strings.Contains(line, "ON UPDATE CURRENT_TIMESTAMP")
 
 	return c, nil
 }
@@ -114,6 +116,30 @@ func (g *Generator) parsePrimaryKey(line string) (primaryKeyName string, err err
 	return primaryKeyName, nil
 }
 
+func (g *Generator) parseSoftDeleteColumn(t *Table) *Column {
+	for _, name := range g.SoftDeleteColumnNameList {
+		for _, c := range t.ColumnList {
+			if c.DbName != name {
+				continue
+			}
+
+			// 时间列以NULL表示未删除，整数列以0表示未删除
+			if c.GoTypeReal == "time.Time" && !c.NotNull {
+				return c
+			}
+
+			switch c.GoTypeReal {
+			case "int32", "int64", "uint32", "uint64":
+				if c.NotNull {
+					return c
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 func (g *Generator) parseTable(lines []string, i *int) (t *Table, err error) {
 	t = newTable()
 
@@ -125,6 +151,10 @@ func (g *Generator) parseTable(lines []string, i *int) (t *Table, err error) {
 	for ; *i < len(lines); *i++ {
 		l = strings.TrimSpace(lines[*i])
 		if strings.HasPrefix(l, ")") {
+			t.SoftDeleteColumn = g.parseSoftDeleteColumn(t)
+			if g.DbTimestamps {
+				t.useDbTimestamps()
+			}
 			return t, nil
 		}
 
@@ -10,6 +10,9 @@ type Column struct {
 	AutoIncrement bool
 	NotNull       bool
 	Unsigned      bool
+
+	DefaultCurrentTimestamp  bool // DEFAULT CURRENT_TIMESTAMP
+	OnUpdateCurrentTimestamp bool // ON UPDATE CURRENT_TIMESTAMP
 }
 
 func (c *Column) IsUniqueIndex(t *Table) bool {
@@ -24,6 +27,44 @@ func (c *Column) IsUniqueIndex(t *Table) bool {
 	return false
 }
 
+func (c *Column) IsNumeric() bool {
+	switch c.GoTypeReal {
+	case "int32", "int64", "uint32", "uint64", "float32", "float64":
+		return true
+	}
+
+	return false
+}
+
+// 按列的小数秒精度截断，保证写回实体的值与数据库存储一致
+func (c *Column) TimeTruncate() string {
+	switch c.Size {
+	case "1":
+		return "100*time.Millisecond"
+	case "2":
+		return "10*time.Millisecond"
+	case "3":
+		return "time.Millisecond"
+	case "4":
+		return "100*time.Microsecond"
+	case "5":
+		return "10*time.Microsecond"
+	case "6":
+		return "time.Microsecond"
+	default:
+		return "time.Second"
+	}
+}
+
+// 时间值转换为列的Go类型
+func (c *Column) TimeValue(v string) string {
+	if c.NotNull {
+		return v
+	}
+
+	return "mysql.NullTime{Time:" + v + ",Valid:true}"
+}
+
 type Index struct {
 	Name   string
 	Column *Column
@@ -43,6 +84,8 @@ type Table struct {
 	CreateTimeColumn     *Column
 	UpdateTimeColumn     *Column
 	UpdateVersionColumn  *Column
+	SoftDeleteColumn     *Column
+	DbTimestampColumns   []*Column // 由数据库默认值维护的create_time/update_time
 	IndexList            []*Index
 	UniqueIndexList      []*Index
 	UnionIndexList       []*UnionIndex
@@ -59,11 +102,12 @@ func newTable() (t *Table) {
 func (t *Table) AddColumn(c *Column) {
 	t.ColumnList = append(t.ColumnList, c)
 
-	if c.DbName == "create_time" {
+	// 仅时间类型的create_time/update_time由生成代码维护
+	if c.DbName == "create_time" && c.GoTypeReal == "time.Time" {
 		t.CreateTimeColumn = c
 	}
 
-	if c.DbName == "update_time" {
+	if c.DbName == "update_time" && c.GoTypeReal == "time.Time" {
 		t.UpdateTimeColumn = c
 	}
 
@@ -71,3 +115,82 @@ func (t *Table) AddColumn(c *Column) {
 		t.UpdateVersionColumn = c
 	}
 }
+
+func (t *Table) AutoIncrementColumn() *Column {
+	for _, c := range t.ColumnList {
+		if c.AutoIncrement {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// create_time/update_time由生成代码以时钟赋值或由数据库维护
+func (t *Table) IsTimestampColumn(c *Column) bool {
+	return c == t.CreateTimeColumn || c == t.UpdateTimeColumn || t.IsDbTimestampColumn(c)
+}
+
+// 由数据库维护的时间列不出现在INSERT中，UPDATE时不赋值
+func (t *Table) IsDbTimestampColumn(c *Column) bool {
+	for _, v := range t.DbTimestampColumns {
+		if v == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 有DEFAULT CURRENT_TIMESTAMP的create_time和有ON UPDATE CURRENT_TIMESTAMP的update_time交由数据库维护
+func (t *Table) useDbTimestamps() {
+	if c := t.CreateTimeColumn; c != nil && c.DefaultCurrentTimestamp {
+		t.DbTimestampColumns = append(t.DbTimestampColumns, c)
+		t.CreateTimeColumn = nil
+	}
+
+	if c := t.UpdateTimeColumn; c != nil && c.DefaultCurrentTimestamp && c.OnUpdateCurrentTimestamp {
+		t.DbTimestampColumns = append(t.DbTimestampColumns, c)
+		t.UpdateTimeColumn = nil
+	}
+}
+
+// ON DUPLICATE KEY UPDATE空操作赋值使用的列
+func (t *Table) duplicatedNoopColumn() *Column {
+	if t.PrimaryColumn != nil {
+		return t.PrimaryColumn
+	}
+
+	if len(t.UniqueIndexList) > 0 {
+		return t.UniqueIndexList[0].Column
+	}
+
+	return t.UniqueUnionIndexList[0].ColumnList[0]
+}
+
+// 软删除条件：未删除
+func (t *Table) SoftDeleteAliveCondition() string {
+	if t.SoftDeleteColumn.GoTypeReal == "time.Time" {
+		return t.SoftDeleteColumn.DbName + " IS NULL"
+	}
+
+	return t.SoftDeleteColumn.DbName + "=0"
+}
+
+// 软删除条件：已删除
+func (t *Table) SoftDeleteDeletedCondition() string {
+	if t.SoftDeleteColumn.GoTypeReal == "time.Time" {
+		return t.SoftDeleteColumn.DbName + " IS NOT NULL"
+	}
+
+	return t.SoftDeleteColumn.DbName + "<>0"
+}
+
+// 软删除赋值，时间列的值作为参数传入
+func (t *Table) SoftDeleteAssignment() string {
+	if t.SoftDeleteColumn.GoTypeReal == "time.Time" {
+		return t.SoftDeleteColumn.DbName + "=?"
+	}
+
+	return t.SoftDeleteColumn.DbName + "=1"
+}
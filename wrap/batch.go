@@ -0,0 +1,137 @@
+package wrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// MySQL单条语句的占位符上限
+const MaxPlaceholders = 65535
+
+// Batch 一批写入对应的行区间[Start,End)
+type Batch struct {
+	Start int
+	End   int
+}
+
+// SplitBatch 将每行rowParams个参数的params切分为多批，每批的占位符数量不超过MaxPlaceholders，
+// 估算字节数不超过max_allowed_packet。fixedParams和fixedSize为每条语句固定部分的参数数量和长度，
+// tx用于首次查询max_allowed_packet
+func (db *DB) SplitBatch(ctx context.Context, tx *Tx, params []interface{}, rowParams int,
+	fixedParams int, fixedSize int) ([]*Batch, error) {
+	if len(params) == 0 || rowParams <= 0 {
+		return nil, nil
+	}
+
+	maxAllowedPacket, err := db.MaxAllowedPacket(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	// 预留协议头及估算误差
+	maxSize := int(maxAllowedPacket) * 9 / 10
+
+	maxRows := (MaxPlaceholders - fixedParams) / rowParams
+	if maxRows < 1 {
+		maxRows = 1
+	}
+
+	rows := len(params) / rowParams
+	var batches []*Batch
+	start := 0
+	size := fixedSize
+	for i := 0; i < rows; i++ {
+		rowSize := rowParams*2 + 2
+		for _, v := range params[i*rowParams : (i+1)*rowParams] {
+			rowSize += EstimateSize(v)
+		}
+
+		if i > start && (i-start >= maxRows || size+rowSize > maxSize) {
+			batches = append(batches, &Batch{Start: start, End: i})
+			start = i
+			size = fixedSize
+		}
+		size += rowSize
+	}
+	batches = append(batches, &Batch{Start: start, End: rows})
+
+	return batches, nil
+}
+
+// ExecBatch 依次执行各批并汇总影响行数，LastInsertId取第一批的值，任一批未写回ID时IdsAssigned为false。
+// atomic为true且tx和ctx中均无事务时，多批写入在同一事务中执行
+func (db *DB) ExecBatch(ctx context.Context, tx *Tx, atomic bool, batches []*Batch,
+	exec func(tx *Tx, b *Batch) (*Result, error)) (result *Result, err error) {
+	if atomic && tx == nil && TxFromContext(ctx) == nil && len(batches) > 1 {
+		err = db.TransactionReadCommitted(ctx, false, func(tx *Tx) (err error) {
+			result, err = db.execBatch(tx, batches, exec)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	}
+
+	return db.execBatch(tx, batches, exec)
+}
+
+func (db *DB) execBatch(tx *Tx, batches []*Batch, exec func(tx *Tx, b *Batch) (*Result, error)) (*Result, error) {
+	var lastInsertId int64
+	var rowsAffected int64
+	idsUnassigned := false
+	for i, b := range batches {
+		result, err := exec(tx, b)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			lastInsertId, err = result.LastInsertId()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		rowsAffected += n
+		idsUnassigned = idsUnassigned || result.idsUnassigned
+	}
+
+	result := NewResult(lastInsertId, rowsAffected)
+	result.idsUnassigned = idsUnassigned
+	return result, nil
+}
+
+// EstimateSize 估算参数发送时占用的字节数
+func EstimateSize(v interface{}) int {
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return 0
+		}
+		v = value
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return 4
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case bool:
+		return 1
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return 8
+	case time.Time:
+		return 26
+	default:
+		return len(fmt.Sprint(v))
+	}
+}
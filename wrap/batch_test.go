@@ -0,0 +1,122 @@
+package wrap
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func batchRanges(batches []*Batch) [][2]int {
+	ranges := make([][2]int, len(batches))
+	for i, b := range batches {
+		ranges[i] = [2]int{b.Start, b.End}
+	}
+	return ranges
+}
+
+func TestSplitBatchPlaceholders(t *testing.T) {
+	db, _ := newMockDB(t)
+	db.SetMaxAllowedPacket(1 << 30)
+
+	// 每行3个参数，固定部分1个参数，每批最多(65535-1)/3=21844行
+	params := make([]interface{}, 3*50000)
+	for i := range params {
+		params[i] = i
+	}
+	batches, err := db.SplitBatch(context.Background(), nil, params, 3, 1, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][2]int{{0, 21844}, {21844, 43688}, {43688, 50000}}
+	ranges := batchRanges(batches)
+	if len(ranges) != len(expected) {
+		t.Fatalf("batches=%v", ranges)
+	}
+	for i := range expected {
+		if ranges[i] != expected[i] {
+			t.Fatalf("batches=%v", ranges)
+		}
+	}
+	for _, b := range batches {
+		if 1+3*(b.End-b.Start) > MaxPlaceholders {
+			t.Fatalf("batch %v exceeds placeholders", b)
+		}
+	}
+}
+
+func TestSplitBatchSize(t *testing.T) {
+	db, _ := newMockDB(t)
+	// 可用900字节，固定部分20字节，每行1*2+2+100=104字节，每批8行
+	db.SetMaxAllowedPacket(1000)
+
+	params := make([]interface{}, 20)
+	for i := range params {
+		params[i] = strings.Repeat("a", 100)
+	}
+	batches, err := db.SplitBatch(context.Background(), nil, params, 1, 0, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranges := batchRanges(batches)
+	if len(ranges) != 3 || ranges[0] != [2]int{0, 8} || ranges[1] != [2]int{8, 16} || ranges[2] != [2]int{16, 20} {
+		t.Fatalf("batches=%v", ranges)
+	}
+
+	// 超过max_allowed_packet的单行独占一批，由服务端报错
+	params = []interface{}{"a", strings.Repeat("b", 2000), "c"}
+	batches, err = db.SplitBatch(context.Background(), nil, params, 1, 0, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranges = batchRanges(batches)
+	if len(ranges) != 3 || ranges[0] != [2]int{0, 1} || ranges[1] != [2]int{1, 2} || ranges[2] != [2]int{2, 3} {
+		t.Fatalf("batches=%v", ranges)
+	}
+
+	batches, err = db.SplitBatch(context.Background(), nil, nil, 1, 0, 20)
+	if err != nil || batches != nil {
+		t.Fatalf("batches=%v err=%v", batches, err)
+	}
+}
+
+// 事务中首次查询max_allowed_packet使用事务的连接，连接池只有一个连接时不会阻塞
+func TestSplitBatchInTx(t *testing.T) {
+	db, mock := newMockDB(t, WithMaxOpenConns(1))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT @@max_allowed_packet").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1 << 20))
+	mock.ExpectQuery("SELECT @@innodb_autoinc_lock_mode,@@auto_increment_increment,VERSION()").
+		WillReturnRows(sqlmock.NewRows([]string{"mode", "increment", "version"}).AddRow(1, 1, "8.0.36"))
+	mock.ExpectCommit()
+	err := db.Transaction(ctx, nil, func(tx *Tx) error {
+		batches, err := db.SplitBatch(tx.Context(), tx, []interface{}{1, 2}, 1, 0, 20)
+		if err != nil {
+			return err
+		}
+		if len(batches) != 1 {
+			t.Fatalf("batches=%v", batchRanges(batches))
+		}
+
+		a, err := db.AutoIncrement(tx.Context(), nil)
+		if err != nil {
+			return err
+		}
+		if a.LockMode != 1 || a.Increment != 1 || a.Returning {
+			t.Fatalf("autoIncrement=%+v", a)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
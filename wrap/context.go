@@ -0,0 +1,61 @@
+package wrap
+
+import (
+	"context"
+	"fmt"
+)
+
+var ErrTxMismatch = ErrorWrap(fmt.Errorf("sql: transaction belongs to another DB"))
+
+type txContextKey struct{}
+
+type tableContextKey struct{}
+
+type methodContextKey struct{}
+
+// NewTxContext 将事务绑定到ctx，生成代码在tx参数为nil时使用ctx中的事务
+func NewTxContext(ctx context.Context, tx *Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+func TxFromContext(ctx context.Context) *Tx {
+	tx, _ := ctx.Value(txContextKey{}).(*Tx)
+	return tx
+}
+
+// WithTable 标记ctx中执行的语句所属的表，用于错误信息
+func WithTable(ctx context.Context, table string) context.Context {
+	return context.WithValue(ctx, tableContextKey{}, table)
+}
+
+func TableFromContext(ctx context.Context) string {
+	table, _ := ctx.Value(tableContextKey{}).(string)
+	return table
+}
+
+// WithMethod 标记ctx中执行的语句所属的DAO方法，如UserAccountDao.SelectList
+func WithMethod(ctx context.Context, method string) context.Context {
+	return context.WithValue(ctx, methodContextKey{}, method)
+}
+
+func MethodFromContext(ctx context.Context) string {
+	method, _ := ctx.Value(methodContextKey{}).(string)
+	return method
+}
+
+// errExecutor 所有操作均返回同一错误
+type errExecutor struct {
+	err error
+}
+
+func (e *errExecutor) Query(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	return nil, e.err
+}
+
+func (e *errExecutor) QueryRow(ctx context.Context, query string, args ...interface{}) *Row {
+	return &Row{err: e.err}
+}
+
+func (e *errExecutor) Exec(ctx context.Context, query string, args ...interface{}) (*Result, error) {
+	return nil, e.err
+}
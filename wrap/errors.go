@@ -0,0 +1,230 @@
+package wrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Error 包装驱动返回的错误，Kind为分类后的哨兵错误，可用errors.Is判断，
+// errors.As可取得原始的*mysql.MySQLError。
+// 分类后返回的是新的*Error，不再是哨兵本身，err==ErrDuplicated不再成立，须改用errors.Is
+type Error struct {
+	Err    error
+	Kind   error
+	Key    string // 冲突的唯一索引或外键约束名
+	Column string // 出错的列名
+
+	// 以下为出错语句的上下文，非语句执行产生的错误为空
+	Op      string // Exec、Query、QueryRow、Scan
+	Query   string
+	Params  []interface{} // 已脱敏
+	Elapsed time.Duration
+	Table   string // 生成代码通过WithTable指定
+	InTx    bool
+}
+
+var ErrNoRows = ErrorWrap(fmt.Errorf("sql: no rows in result set"))
+
+// 分类哨兵错误不能通过ErrorWrap创建，避免初始化循环
+var (
+	ErrDuplicated      = &Error{Err: errors.New("sql: duplicated")}
+	ErrForeignKey      = &Error{Err: errors.New("sql: foreign key constraint fails")}
+	ErrDataTooLong     = &Error{Err: errors.New("sql: data too long")}
+	ErrNotNull         = &Error{Err: errors.New("sql: column cannot be null")}
+	ErrDeadlock        = &Error{Err: errors.New("sql: deadlock")}
+	ErrLockWaitTimeout = &Error{Err: errors.New("sql: lock wait timeout")}
+	ErrConnectionLost  = &Error{Err: errors.New("sql: connection lost")}
+	ErrReadOnly        = &Error{Err: errors.New("sql: read only")}
+)
+
+// Error()中语句和参数的最大长度，批量语句可能很长，Query和Params字段保留完整内容
+const (
+	errorQueryMaxLength  = 512
+	errorParamsMaxLength = 32
+)
+
+func (e *Error) Error() string {
+	if e.Op == "" {
+		return e.Err.Error()
+	}
+
+	params := fmt.Sprint(e.Params)
+	if len(e.Params) > errorParamsMaxLength {
+		params = fmt.Sprintf("%v...(%d params)", e.Params[:errorParamsMaxLength], len(e.Params))
+	}
+
+	return fmt.Sprintf("%s: %s (table=%s tx=%t elapsed=%s query=%q params=%s)",
+		e.Op, e.Err.Error(), e.Table, e.InTx, e.Elapsed, truncateQuery(e.Query, errorQueryMaxLength), params)
+}
+
+func truncateQuery(query string, n int) string {
+	if len(query) <= n {
+		return query
+	}
+
+	// 不截断多字节字符
+	for n > 0 && !utf8.RuneStart(query[n]) {
+		n--
+	}
+	return fmt.Sprintf("%s...(%d bytes)", query[:n], len(query))
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+func ErrorWrap(err error) *Error {
+	e := &Error{Err: err}
+	e.classify()
+	return e
+}
+
+// ErrorClass 错误分类名，用于指标标签
+func ErrorClass(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrDuplicated):
+		return "duplicated"
+	case errors.Is(err, ErrForeignKey):
+		return "foreign_key"
+	case errors.Is(err, ErrDataTooLong):
+		return "data_too_long"
+	case errors.Is(err, ErrNotNull):
+		return "not_null"
+	case errors.Is(err, ErrDeadlock):
+		return "deadlock"
+	case errors.Is(err, ErrLockWaitTimeout):
+		return "lock_wait_timeout"
+	case errors.Is(err, ErrConnectionLost):
+		return "connection_lost"
+	case errors.Is(err, ErrReadOnly):
+		return "read_only"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	default:
+		return "other"
+	}
+}
+
+var (
+	keyRegexp        = regexp.MustCompile(`for key '([^']+)'`)
+	foreignKeyRegexp = regexp.MustCompile("CONSTRAINT `([^`]+)` FOREIGN KEY \\(`([^`]+)`")
+	columnRegexp     = regexp.MustCompile(`(?:column|Column|Field) '([^']+)'`)
+)
+
+func (e *Error) classify() {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(e.Err, &mysqlErr) {
+		if errors.Is(e.Err, mysql.ErrInvalidConn) || errors.Is(e.Err, driver.ErrBadConn) {
+			e.Kind = ErrConnectionLost
+		}
+		return
+	}
+
+	switch mysqlErr.Number {
+	case 1062, 1586:
+		e.Kind = ErrDuplicated
+		// MySQL 8.0起索引名带表名前缀
+		if m := keyRegexp.FindStringSubmatch(mysqlErr.Message); m != nil {
+			e.Key = m[1][strings.LastIndex(m[1], ".")+1:]
+		}
+	case 1451, 1452:
+		e.Kind = ErrForeignKey
+		if m := foreignKeyRegexp.FindStringSubmatch(mysqlErr.Message); m != nil {
+			e.Key = m[1]
+			e.Column = m[2]
+		}
+	case 1406:
+		e.Kind = ErrDataTooLong
+		e.Column = matchColumn(mysqlErr.Message)
+	case 1048, 1364:
+		e.Kind = ErrNotNull
+		e.Column = matchColumn(mysqlErr.Message)
+	case 1213:
+		e.Kind = ErrDeadlock
+	case 1205:
+		e.Kind = ErrLockWaitTimeout
+	case 1053, 1927, 2006, 2013:
+		e.Kind = ErrConnectionLost
+	case 1290, 1792, 1836:
+		e.Kind = ErrReadOnly
+	}
+}
+
+func matchColumn(message string) string {
+	m := columnRegexp.FindStringSubmatch(message)
+	if m == nil {
+		return ""
+	}
+
+	return m[1]
+}
+
+// queryInfo 语句执行信息，出错时填充到Error
+type queryInfo struct {
+	query string
+	args  []interface{}
+	table string
+	inTx  bool
+	start time.Time
+}
+
+func newQueryInfo(ctx context.Context, query string, args []interface{}, inTx bool) *queryInfo {
+	return &queryInfo{query: query, args: args, table: TableFromContext(ctx), inTx: inTx, start: time.Now()}
+}
+
+func (db *DB) wrapError(err error, op string, info *queryInfo) *Error {
+	e := ErrorWrap(err)
+	e.Op = op
+	if info != nil {
+		e.Query = info.query
+		e.Params = db.redactParams(info.args)
+		e.Elapsed = time.Since(info.start)
+		e.Table = info.table
+		e.InTx = info.inTx
+	}
+
+	return e
+}
+
+// SetParamsRedactor 替换Error中参数的脱敏方式，默认为RedactParams
+func (db *DB) SetParamsRedactor(f func(args []interface{}) []interface{}) {
+	db.redactParams = f
+}
+
+// RedactParams 字符串和字节只保留长度，其余参数原样保留
+func RedactParams(args []interface{}) []interface{} {
+	params := make([]interface{}, len(args))
+	for i, v := range args {
+		if valuer, ok := v.(driver.Valuer); ok {
+			if value, err := valuer.Value(); err == nil {
+				v = value
+			}
+		}
+
+		switch v := v.(type) {
+		case string:
+			params[i] = fmt.Sprintf("string(%d)", len(v))
+		case []byte:
+			params[i] = fmt.Sprintf("[]byte(%d)", len(v))
+		default:
+			params[i] = v
+		}
+	}
+
+	return params
+}
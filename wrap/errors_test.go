@@ -0,0 +1,67 @@
+package wrap
+
+import (
+	"context"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"strings"
+	"testing"
+)
+
+func TestErrorDuplicated(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectExec("INSERT INTO user(name) VALUES (?)").WithArgs("a").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a' for key 'user.uk_name'"})
+
+	_, err := db.Exec(context.Background(), "INSERT INTO user(name) VALUES (?)", "a")
+	if !errors.Is(err, ErrDuplicated) {
+		t.Fatalf("err=%v", err)
+	}
+	// 返回的是带上下文的新错误，不是哨兵本身
+	if err == error(ErrDuplicated) {
+		t.Fatal("err is sentinel")
+	}
+	if ErrorClass(err) != "duplicated" {
+		t.Fatalf("class=%s", ErrorClass(err))
+	}
+
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("err=%v", err)
+	}
+	if e.Key != "uk_name" || e.Op != "Exec" || e.Query != "INSERT INTO user(name) VALUES (?)" {
+		t.Fatalf("err=%+v", e)
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != 1062 {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestErrorTruncate(t *testing.T) {
+	query := "INSERT INTO t(name) VALUES " + strings.Repeat("(?),", 1000) + "(?)"
+	params := make([]interface{}, 1001)
+	for i := range params {
+		params[i] = i
+	}
+
+	e := &Error{Err: errors.New("failed"), Op: "Exec", Query: query, Params: params}
+	message := e.Error()
+	if len(message) > errorQueryMaxLength+512 {
+		t.Fatalf("len=%d", len(message))
+	}
+	if !strings.Contains(message, "...(4030 bytes)") || !strings.Contains(message, "...(1001 params)") {
+		t.Fatal(message)
+	}
+	if e.Query != query || len(e.Params) != 1001 {
+		t.Fatal("fields truncated")
+	}
+
+	// 不截断多字节字符
+	s := truncateQuery(strings.Repeat("中", 10), 4)
+	if s != "中...(30 bytes)" {
+		t.Fatal(s)
+	}
+}
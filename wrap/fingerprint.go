@@ -0,0 +1,86 @@
+package wrap
+
+import (
+	"strings"
+)
+
+// Fingerprint 归一化语句用于聚合同类语句：常量和占位符替换为?，IN列表及多行VALUES折叠，
+// 去掉注释，空白统一，转为小写
+func Fingerprint(query string) string {
+	tokens := tokenize(query)
+
+	var parts []string
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case isValueToken(t):
+			parts = append(parts, "?")
+		case isKeyword(t, "IN") && i+1 < len(tokens) && tokens[i+1].text == "(":
+			parts = append(parts, "in")
+			if end := valueListEnd(tokens, i+1); end > 0 {
+				parts = append(parts, "(", "?+", ")")
+				i = end
+			}
+		case t.text == "," && len(parts) > 0 && parts[len(parts)-1] == ")" && inValues(parts) &&
+			i+1 < len(tokens) && tokens[i+1].text == "(":
+			// VALUES (...),(...) 只保留第一行
+			end := valueListEnd(tokens, i+1)
+			if end < 0 {
+				parts = append(parts, ",")
+				continue
+			}
+			i = end
+		default:
+			parts = append(parts, strings.ToLower(t.text))
+		}
+	}
+
+	buf := strings.Builder{}
+	for i, p := range parts {
+		if i > 0 && p != "," && p != ")" && parts[i-1] != "(" {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(p)
+	}
+
+	return buf.String()
+}
+
+func isValueToken(t token) bool {
+	return t.kind == tokenString || t.kind == tokenPlaceholder ||
+		t.kind == tokenIdent && len(t.text) > 0 && t.text[0] >= '0' && t.text[0] <= '9'
+}
+
+// valueListEnd tokens[start]为(，括号内只有常量、占位符和逗号时返回对应)的下标，否则返回-1
+func valueListEnd(tokens []token, start int) int {
+	for i := start + 1; i < len(tokens); i++ {
+		switch {
+		case tokens[i].text == ")":
+			return i
+		case tokens[i].text == "," || isValueToken(tokens[i]) || isKeyword(tokens[i], "NULL"):
+		default:
+			return -1
+		}
+	}
+
+	return -1
+}
+
+// inValues 是否处于最近的VALUES值列表中
+func inValues(parts []string) bool {
+	depth := 0
+	for i := len(parts) - 1; i >= 0; i-- {
+		switch parts[i] {
+		case ")":
+			depth++
+		case "(":
+			depth--
+		case "values", "value":
+			return depth == 0
+		case "on", "select", "where", "set", "from", "as":
+			return false
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,30 @@
+package wrap
+
+import (
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		query       string
+		fingerprint string
+	}{
+		{"SELECT id,name FROM user WHERE id=? AND name='a' AND age>18", "select id, name from user where id = ? and name = ? and age > ?"},
+		{"select id from user where id in (1,2,3)", "select id from user where id in (?+)"},
+		{"SELECT id FROM user WHERE id IN (?,?) AND type NOT IN ('a')", "select id from user where id in (?+) and type not in (?+)"},
+		{"SELECT id FROM user WHERE id IN (SELECT uid FROM t)", "select id from user where id in (select uid from t)"},
+		{"INSERT INTO user (name,age) VALUES (?,?),(?,?),('a',NULL)", "insert into user (name, age) values (?, ?)"},
+		{"INSERT INTO user (name) VALUES (?) ON DUPLICATE KEY UPDATE name=VALUES(name)", "insert into user (name) values (?) on duplicate key update name = values (name)"},
+		{"SELECT /* hint */ id FROM user -- by id\nWHERE id=? # end", "select id from user where id = ?"},
+		{"SELECT  id\n\tFROM `user`", "select id from user"},
+		// 空的反引号标识符
+		{"SELECT `` FROM t", "select  from t"},
+	}
+
+	for _, test := range tests {
+		fingerprint := Fingerprint(test.query)
+		if fingerprint != test.fingerprint {
+			t.Errorf("Fingerprint(%q)=%q, expected %q", test.query, fingerprint, test.fingerprint)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package wrap
+
+import (
+	"fmt"
+)
+
+// OnCommit 注册事务提交后执行的函数，按注册顺序执行。
+// 在保存点中注册时，保存点释放后归入外层事务，回滚到保存点时丢弃
+func (tx *Tx) OnCommit(f func()) {
+	tx.commitHooks = append(tx.commitHooks, f)
+}
+
+// OnRollback 注册事务回滚后执行的函数，参数为导致回滚的错误
+func (tx *Tx) OnRollback(f func(err error)) {
+	tx.rollbackHooks = append(tx.rollbackHooks, f)
+}
+
+func (tx *Tx) runCommitHooks() {
+	for _, f := range tx.commitHooks {
+		tx.db.runHook("Tx.OnCommit", f)
+	}
+	tx.commitHooks = nil
+	tx.rollbackHooks = nil
+}
+
+func (tx *Tx) runRollbackHooks(err error) {
+	for _, f := range tx.rollbackHooks {
+		tx.db.runHook("Tx.OnRollback", func() { f(err) })
+	}
+	tx.commitHooks = nil
+	tx.rollbackHooks = nil
+}
+
+// 保存点释放后由外层事务执行
+func (tx *Tx) mergeHooks(child *Tx) {
+	tx.commitHooks = append(tx.commitHooks, child.commitHooks...)
+	tx.rollbackHooks = append(tx.rollbackHooks, child.rollbackHooks...)
+}
+
+// runHook hook中的panic只记录，不影响事务结果和后续hook
+func (db *DB) runHook(name string, f func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			db.logger.Log(LogError, name, "error", fmt.Errorf("hook panic: %v", p))
+		}
+	}()
+
+	f()
+}
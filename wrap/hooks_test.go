@@ -0,0 +1,86 @@
+package wrap
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"strings"
+	"testing"
+)
+
+func TestHooksSavepoint(t *testing.T) {
+	db, mock := newMockDB(t)
+	failed := errors.New("failed")
+	var calls []string
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := db.Transaction(context.Background(), nil, func(tx *Tx) error {
+		tx.OnCommit(func() { calls = append(calls, "commit:outer") })
+
+		// 释放的保存点中注册的hook归入外层事务
+		err := tx.Transaction(tx.Context(), func(tx *Tx) error {
+			tx.OnCommit(func() { calls = append(calls, "commit:released") })
+			tx.OnRollback(func(err error) { calls = append(calls, "rollback:released") })
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// 回滚到保存点时立即执行OnRollback，OnCommit被丢弃
+		err = tx.Transaction(tx.Context(), func(tx *Tx) error {
+			tx.OnCommit(func() { calls = append(calls, "commit:rolledback") })
+			tx.OnRollback(func(err error) { calls = append(calls, "rollback:rolledback:"+err.Error()) })
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Fatalf("err=%v", err)
+		}
+		if strings.Join(calls, ",") != "rollback:rolledback:failed" {
+			t.Fatalf("calls=%v", calls)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(calls, ",") != "rollback:rolledback:failed,commit:outer,commit:released" {
+		t.Fatalf("calls=%v", calls)
+	}
+
+	// 外层事务回滚时执行释放的保存点中注册的OnRollback
+	calls = nil
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = db.Transaction(context.Background(), nil, func(tx *Tx) error {
+		err := tx.Transaction(tx.Context(), func(tx *Tx) error {
+			tx.OnCommit(func() { calls = append(calls, "commit:released") })
+			tx.OnRollback(func(err error) { calls = append(calls, "rollback:released") })
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("err=%v", err)
+	}
+	if strings.Join(calls, ",") != "rollback:released" {
+		t.Fatalf("calls=%v", calls)
+	}
+
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,94 @@
+package wrap
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Call 一次数据库操作。拦截器可在调用next前改写Query和Args，调用后读取结果
+type Call struct {
+	Op        string // Exec、Query、QueryRow、Prepare、Begin、Commit、Rollback
+	Name      string // 日志中的操作名，如DB.Exec、Tx.Query、Stmt.Exec
+	Query     string // Stmt的操作改写无效
+	Args      []interface{}
+	InTx      bool
+	TxOptions *sql.TxOptions // 仅Begin
+
+	Result sql.Result // Exec
+	Rows   *sql.Rows  // Query
+	Row    *sql.Row   // QueryRow
+	Stmt   *sql.Stmt  // Prepare
+	Tx     *sql.Tx    // Begin的结果，Commit、Rollback时为当前事务，ConsistentSnapshot事务为nil
+
+	RowsReturned int64 // Query、QueryRow读取的行数，在AfterClose中有效
+
+	conn       conn
+	afterClose []func()
+}
+
+// AfterClose 注册在结果集关闭后执行的函数，此时连接已空闲，Exec等操作在执行后立即调用
+func (c *Call) AfterClose(f func()) {
+	c.afterClose = append(c.afterClose, f)
+}
+
+func (c *Call) runAfterClose() {
+	for _, f := range c.afterClose {
+		f()
+	}
+	c.afterClose = nil
+}
+
+type Handler func(ctx context.Context, call *Call) error
+
+// Interceptor 包装一次数据库操作，不调用next时直接返回的错误作为操作结果，可用于故障注入
+type Interceptor func(ctx context.Context, call *Call, next Handler) error
+
+// WithInterceptor 先添加的拦截器在外层
+func WithInterceptor(interceptors ...Interceptor) Option {
+	return func(db *DB) {
+		db.interceptors = append(db.interceptors, interceptors...)
+	}
+}
+
+// invoke 日志在最内层，记录实际执行的语句
+func (db *DB) invoke(ctx context.Context, call *Call, h Handler) error {
+	next := h
+	h = func(ctx context.Context, call *Call) error {
+		return db.logInterceptor(ctx, call, next)
+	}
+	for i := len(db.interceptors) - 1; i >= 0; i-- {
+		interceptor := db.interceptors[i]
+		next := h
+		h = func(ctx context.Context, call *Call) error {
+			return interceptor(ctx, call, next)
+		}
+	}
+
+	return h(ctx, call)
+}
+
+func (db *DB) logInterceptor(ctx context.Context, call *Call, next Handler) error {
+	switch call.Op {
+	case "Begin", "Commit", "Rollback":
+		db.logOp(call.Name)
+	default:
+		db.logQuery(ctx, call.Name, call.Query, call.Args)
+	}
+
+	err := next(ctx, call)
+	// 超时等情况下事务已由database/sql回滚
+	if err != nil && !(call.Op == "Rollback" && err == sql.ErrTxDone) {
+		db.logger.Log(LogError, call.Name, "error", err)
+	}
+
+	return err
+}
+
+func hookInterceptor(hook Hook) Interceptor {
+	return func(ctx context.Context, call *Call, next Handler) error {
+		ctx = hook.Before(ctx, call.Name, call.Query, call.Args)
+		err := next(ctx, call)
+		hook.After(ctx, call.Name, call.Query, call.Args, err)
+		return err
+	}
+}
@@ -0,0 +1,95 @@
+package wrap
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"strings"
+	"testing"
+)
+
+func TestInterceptorOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, call *Call, next Handler) error {
+			calls = append(calls, name+":before:"+call.Op)
+			err := next(ctx, call)
+			calls = append(calls, name+":after:"+call.Op)
+			return err
+		}
+	}
+	rewrite := func(ctx context.Context, call *Call, next Handler) error {
+		call.Query = strings.Replace(call.Query, "t", "t_1", 1)
+		call.AfterClose(func() {
+			calls = append(calls, "afterClose:"+call.Op)
+		})
+		return next(ctx, call)
+	}
+	db, mock := newMockDB(t, WithInterceptor(record("a"), record("b"), rewrite))
+
+	mock.ExpectQuery("SELECT id FROM t_1").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	rows, err := db.Query(context.Background(), "SELECT id FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+
+	// 先添加的在外层，AfterClose在结果集关闭后执行
+	expected := "a:before:Query,b:before:Query,b:after:Query,a:after:Query,afterClose:Query"
+	if strings.Join(calls, ",") != expected {
+		t.Fatalf("calls=%v", calls)
+	}
+
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	injected := errors.New("injected")
+	var calls []string
+	db, mock := newMockDB(t,
+		WithInterceptor(func(ctx context.Context, call *Call, next Handler) error {
+			err := next(ctx, call)
+			calls = append(calls, "outer:"+call.Op)
+			return err
+		}),
+		WithInterceptor(func(ctx context.Context, call *Call, next Handler) error {
+			if call.Op == "Exec" {
+				return injected
+			}
+			return next(ctx, call)
+		}))
+
+	// 不调用next时语句不执行，错误原样向外层返回
+	_, err := db.Exec(context.Background(), "DELETE FROM t")
+	if !errors.Is(err, injected) {
+		t.Fatalf("err=%v", err)
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.Op != "Exec" || e.Query != "DELETE FROM t" {
+		t.Fatalf("err=%v", err)
+	}
+
+	// 事务中注入的错误使事务回滚
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = db.Transaction(context.Background(), nil, func(tx *Tx) error {
+		_, err := tx.Exec(tx.Context(), "DELETE FROM t")
+		return err
+	})
+	if !errors.Is(err, injected) {
+		t.Fatalf("err=%v", err)
+	}
+	if strings.Join(calls, ",") != "outer:Exec,outer:Begin,outer:Exec,outer:Rollback" {
+		t.Fatalf("calls=%v", calls)
+	}
+
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
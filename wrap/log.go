@@ -0,0 +1,410 @@
+package wrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type LogLevel int8
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+	LogOff
+)
+
+// LogConfig 语句日志配置，出错日志不受级别和采样影响
+type LogConfig struct {
+	Level LogLevel // 默认级别
+	// 按操作指定级别，操作名如DB.Exec、Tx.Query、Stmt.Exec、DB.Prepare、Begin、Commit、Rollback
+	Levels map[string]LogLevel
+	// 参数对应的列名包含其中任一字符串时输出***，不区分大小写，如password、token
+	RedactColumns []string
+	// 语句日志采样比例，取值(0,1)时生效
+	SampleRate float64
+}
+
+var DefaultLogConfig = &LogConfig{
+	Level:         LogDebug,
+	RedactColumns: []string{"password", "token", "secret"},
+}
+
+func (db *DB) SetLogConfig(c *LogConfig) {
+	db.logConfig = c
+}
+
+func (db *DB) logLevel(name string) LogLevel {
+	if level, ok := db.logConfig.Levels[name]; ok {
+		return level
+	}
+
+	return db.logConfig.Level
+}
+
+func (db *DB) logEnabled(level LogLevel) bool {
+	return level < LogOff && db.logger.Enabled(level)
+}
+
+// logOp 记录Begin、Commit等不带语句的操作
+func (db *DB) logOp(name string, keysAndValues ...interface{}) {
+	level := db.logLevel(name)
+	if db.logEnabled(level) {
+		db.logger.Log(level, name, keysAndValues...)
+	}
+}
+
+// logQuery 级别未开启或未被采样时不做参数拼接
+func (db *DB) logQuery(ctx context.Context, name string, query string, args []interface{}) {
+	level := db.logLevel(name)
+	if !db.logEnabled(level) {
+		return
+	}
+
+	rate := db.logConfig.SampleRate
+	if rate > 0 && rate < 1 && rand.Float64() >= rate {
+		return
+	}
+
+	db.logger.Log(level, name, "ctx", ctx.Err(), "query", interpolate(query, args, db.redactColumn))
+}
+
+func (db *DB) redactColumn(column string) bool {
+	column = strings.ToLower(column)
+	for _, v := range db.logConfig.RedactColumns {
+		if strings.Contains(column, strings.ToLower(v)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var dsnPasswordRegexp = regexp.MustCompile(`^([^:@/]*):.*@`)
+
+// RedactDSN 隐藏连接串中的密码
+func RedactDSN(dsn string) string {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err == nil && cfg.Passwd != "" {
+		cfg.Passwd = "***"
+		return cfg.FormatDSN()
+	}
+
+	return dsnPasswordRegexp.ReplaceAllString(dsn, "$1:***@")
+}
+
+// Interpolate 将参数代入语句中的占位符，仅用于日志输出，字符串、注释中的?不做替换
+func Interpolate(query string, args []interface{}) string {
+	return interpolate(query, args, nil)
+}
+
+func interpolate(query string, args []interface{}, redact func(column string) bool) string {
+	tokens := tokenize(query)
+	columns := placeholderColumns(tokens)
+
+	buf := strings.Builder{}
+	last := 0
+	n := 0
+	for _, t := range tokens {
+		if t.kind != tokenPlaceholder {
+			continue
+		}
+
+		buf.WriteString(query[last:t.start])
+		last = t.end
+		if n >= len(args) {
+			buf.WriteString("?")
+		} else if redact != nil && columns[n] != "" && redact(columns[n]) {
+			buf.WriteString("'***'")
+		} else {
+			buf.WriteString(formatValue(args[n]))
+		}
+		n++
+	}
+	buf.WriteString(query[last:])
+
+	return buf.String()
+}
+
+func formatValue(v interface{}) string {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return "NULL"
+	}
+
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return "?"
+		}
+		v = value
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return quote(v)
+	case []byte:
+		return "X'" + hex.EncodeToString(v) + "'"
+	case time.Time:
+		return quote(v.Format("2006-01-02 15:04:05.999999"))
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	default:
+		return quote(fmt.Sprint(v))
+	}
+}
+
+func quote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+const (
+	tokenIdent = iota
+	tokenString
+	tokenPlaceholder
+	tokenSymbol
+)
+
+type token struct {
+	kind  int
+	text  string // 标识符去掉反引号
+	start int
+	end   int
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '.' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// tokenize 跳过注释，字符串和反引号标识符按MySQL规则处理转义
+func tokenize(query string) []token {
+	var tokens []token
+	for i := 0; i < len(query); {
+		c := query[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '#' || c == '-' && strings.HasPrefix(query[i:], "-- "):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 4
+			}
+		case c == '\'' || c == '"' || c == '`':
+			i++
+			for i < len(query) {
+				if query[i] == '\\' && c != '`' {
+					i += 2
+					continue
+				}
+				if query[i] == c {
+					if i+1 < len(query) && query[i+1] == c {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+			if i > len(query) {
+				i = len(query)
+			}
+			if c == '`' {
+				tokens = append(tokens, token{kind: tokenIdent, text: strings.Trim(query[start:i], "`"), start: start, end: i})
+			} else {
+				tokens = append(tokens, token{kind: tokenString, text: query[start:i], start: start, end: i})
+			}
+		case c == '?':
+			i++
+			tokens = append(tokens, token{kind: tokenPlaceholder, text: "?", start: start, end: i})
+		case isIdentChar(c):
+			for i < len(query) && isIdentChar(query[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: query[start:i], start: start, end: i})
+		case strings.ContainsRune("<>!=", rune(c)):
+			for i < len(query) && strings.ContainsRune("<>!=", rune(query[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: query[start:i], start: start, end: i})
+		default:
+			i++
+			tokens = append(tokens, token{kind: tokenSymbol, text: query[start:i], start: start, end: i})
+		}
+	}
+
+	return tokens
+}
+
+func isKeyword(t token, keyword string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+func isComparison(t token) bool {
+	if t.kind == tokenSymbol {
+		switch t.text {
+		case "=", "<>", "!=", "<", ">", "<=", ">=", "<=>":
+			return true
+		}
+	}
+
+	return isKeyword(t, "LIKE")
+}
+
+// placeholderColumns 推断每个占位符对应的列名，无法推断时为空。
+// 支持col=?、col IN (?,...)、col BETWEEN ? AND ?、INSERT列表及CASE col WHEN ? THEN ?
+func placeholderColumns(tokens []token) []string {
+	var columns []string
+	var insertColumns []string
+	inValues := false
+	tupleDepth := 0
+	tuplePos := 0
+	depth := 0
+	inColumn := ""
+	inDepth := 0
+	caseColumn := ""
+	caseTarget := ""
+	betweenColumn := ""
+
+	for i, t := range tokens {
+		switch {
+		case isKeyword(t, "INSERT") || isKeyword(t, "REPLACE"):
+			insertColumns = nil
+			// INSERT [INTO] tbl (col,...) VALUES
+			j := i + 1
+			for j < len(tokens) && tokens[j].kind == tokenIdent && !isKeyword(tokens[j], "VALUES") {
+				j++
+			}
+			if j < len(tokens) && tokens[j].text == "(" {
+				for j++; j < len(tokens) && tokens[j].text != ")"; j++ {
+					if tokens[j].kind == tokenIdent {
+						insertColumns = append(insertColumns, tokens[j].text)
+					}
+				}
+			}
+		case isKeyword(t, "VALUES") || isKeyword(t, "VALUE"):
+			// ON DUPLICATE KEY UPDATE中的VALUES(col)不是值列表
+			if i+1 < len(tokens) && tokens[i+1].text == "(" && len(insertColumns) > 0 && !inValues &&
+				!(i > 0 && tokens[i-1].text == "=") {
+				inValues = true
+				tupleDepth = depth
+			}
+		case inValues && depth == tupleDepth && (isKeyword(t, "ON") || isKeyword(t, "AS") || isKeyword(t, "RETURNING")):
+			inValues = false
+		case isKeyword(t, "IN") && i+1 < len(tokens) && tokens[i+1].text == "(" && i > 0:
+			inColumn = tokens[i-1].text
+			if isKeyword(tokens[i-1], "NOT") && i > 1 {
+				inColumn = tokens[i-2].text
+			}
+			inDepth = depth + 1
+		case isKeyword(t, "BETWEEN") && i > 0:
+			betweenColumn = tokens[i-1].text
+		case isKeyword(t, "CASE"):
+			caseTarget = ""
+			if i > 1 && tokens[i-1].text == "=" {
+				caseTarget = tokens[i-2].text
+			}
+			caseColumn = ""
+			if i+1 < len(tokens) && tokens[i+1].kind == tokenIdent && !isKeyword(tokens[i+1], "WHEN") {
+				caseColumn = tokens[i+1].text
+			}
+		case isKeyword(t, "END"):
+			caseColumn = ""
+			caseTarget = ""
+		case t.text == "(":
+			depth++
+			if inValues && depth == tupleDepth+1 {
+				tuplePos = 0
+			}
+		case t.text == ")":
+			if depth == inDepth {
+				inColumn = ""
+			}
+			depth--
+		case t.text == ",":
+			if inValues && depth == tupleDepth+1 {
+				tuplePos++
+			}
+		case t.kind == tokenPlaceholder:
+			column := ""
+			prev := token{}
+			if i > 0 {
+				prev = tokens[i-1]
+			}
+			switch {
+			case inValues && depth == tupleDepth+1:
+				column = insertColumns[tuplePos%len(insertColumns)]
+			case inColumn != "" && depth == inDepth:
+				column = inColumn
+			case isComparison(prev) && i > 1 && tokens[i-2].kind == tokenIdent:
+				column = tokens[i-2].text
+			case isKeyword(prev, "WHEN"):
+				column = caseColumn
+			case isKeyword(prev, "THEN"):
+				column = caseTarget
+			case isKeyword(prev, "BETWEEN") || isKeyword(prev, "AND") && betweenColumn != "":
+				column = betweenColumn
+				if isKeyword(prev, "AND") {
+					betweenColumn = ""
+				}
+			}
+			// 去掉表名前缀
+			columns = append(columns, column[strings.LastIndex(column, ".")+1:])
+		}
+	}
+
+	return columns
+}
+
+// SanitizeQuery 将语句中的字符串和数字常量替换为?，用于上报语句文本
+func SanitizeQuery(query string) string {
+	buf := strings.Builder{}
+	last := 0
+	for _, t := range tokenize(query) {
+		if t.kind == tokenString || t.kind == tokenIdent && len(t.text) > 0 && t.text[0] >= '0' && t.text[0] <= '9' && query[t.start] != '`' {
+			buf.WriteString(query[last:t.start])
+			buf.WriteString("?")
+			last = t.end
+		}
+	}
+	buf.WriteString(query[last:])
+
+	return buf.String()
+}
+
+// logArgs 按RedactColumns脱敏后的参数
+func (db *DB) logArgs(query string, args []interface{}) []interface{} {
+	columns := placeholderColumns(tokenize(query))
+	values := make([]interface{}, len(args))
+	for i, v := range args {
+		if i < len(columns) && columns[i] != "" && db.redactColumn(columns[i]) {
+			values[i] = "***"
+		} else {
+			values[i] = formatValue(v)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,99 @@
+package wrap
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		query  string
+		tokens string
+	}{
+		{"SELECT a FROM t WHERE b=?", "SELECT|a|FROM|t|WHERE|b|=|?"},
+		{"SELECT a -- x=?\nFROM t # y=?\n/* z=? */WHERE b>=?", "SELECT|a|FROM|t|WHERE|b|>=|?"},
+		{"SELECT 'a?''b', \"c\\\"?\" FROM `t``?` WHERE a-?", "SELECT|'a?''b'|,|\"c\\\"?\"|FROM|t``?|WHERE|a|-|?"},
+		{"SELECT a FROM t WHERE b='unterminated", "SELECT|a|FROM|t|WHERE|b|=|'unterminated"},
+		{"SELECT a /* unterminated ?", "SELECT|a"},
+	}
+
+	for _, test := range tests {
+		var texts []string
+		for _, token := range tokenize(test.query) {
+			texts = append(texts, token.text)
+		}
+		if s := strings.Join(texts, "|"); s != test.tokens {
+			t.Errorf("tokenize(%q)=%s, expected %s", test.query, s, test.tokens)
+		}
+	}
+}
+
+func TestPlaceholderColumns(t *testing.T) {
+	tests := []struct {
+		query   string
+		columns string
+	}{
+		{"SELECT * FROM user WHERE name=? AND u.password<>? AND age>?", "name,password,age"},
+		{"SELECT * FROM user WHERE id IN (?,?) AND name NOT IN (?) AND age BETWEEN ? AND ? AND a LIKE ?", "id,id,name,age,age,a"},
+		{"INSERT INTO user(name,`password`) VALUES (?,?),(?,?)", "name,password,name,password"},
+		{"INSERT INTO user(name,password) VALUES (?,?) ON DUPLICATE KEY UPDATE password=VALUES(password),token=?", "name,password,token"},
+		{"INSERT INTO user(name,password) VALUES (?,?) AS new ON DUPLICATE KEY UPDATE password=new.password,token=?", "name,password,token"},
+		{"UPDATE user SET password=CASE id WHEN ? THEN ? WHEN ? THEN ? END WHERE id IN (?,?)", "id,password,id,password,id,id"},
+		{"SELECT * FROM user WHERE name='?' AND a=? -- b=?", "a"},
+		{"SELECT * FROM user LIMIT ?,?", ","},
+	}
+
+	for _, test := range tests {
+		columns := strings.Join(placeholderColumns(tokenize(test.query)), ",")
+		if columns != test.columns {
+			t.Errorf("placeholderColumns(%q)=%s, expected %s", test.query, columns, test.columns)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	redact := func(column string) bool {
+		return column == "password"
+	}
+	tests := []struct {
+		query  string
+		args   []interface{}
+		result string
+	}{
+		{"SELECT * FROM t WHERE a=? AND b='?' AND c LIKE '%?%' /* ? */", []interface{}{1}, "SELECT * FROM t WHERE a=1 AND b='?' AND c LIKE '%?%' /* ? */"},
+		{"SELECT * FROM t WHERE a LIKE ? AND b=?", []interface{}{"%a'b\\%"}, "SELECT * FROM t WHERE a LIKE '%a\\'b\\\\%' AND b=?"},
+		{"SELECT * FROM t WHERE a=? AND b=? AND c=? AND d=? AND e=?", []interface{}{nil, true, []byte{1, 255}, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), (*int)(nil)},
+			"SELECT * FROM t WHERE a=NULL AND b=1 AND c=X'01ff' AND d='2024-01-02 03:04:05' AND e=NULL"},
+		{"INSERT INTO user(name,password) VALUES (?,?) AS new ON DUPLICATE KEY UPDATE password=new.password", []interface{}{"a", "secret"},
+			"INSERT INTO user(name,password) VALUES ('a','***') AS new ON DUPLICATE KEY UPDATE password=new.password"},
+		{"UPDATE user SET password=CASE id WHEN ? THEN ? END WHERE id IN (?)", []interface{}{1, "secret", 1},
+			"UPDATE user SET password=CASE id WHEN 1 THEN '***' END WHERE id IN (1)"},
+	}
+
+	for _, test := range tests {
+		result := interpolate(test.query, test.args, redact)
+		if result != test.result {
+			t.Errorf("interpolate(%q)=%s, expected %s", test.query, result, test.result)
+		}
+	}
+}
+
+func TestRedactDSN(t *testing.T) {
+	tests := []struct {
+		dsn    string
+		result string
+	}{
+		{"root:secret@tcp(127.0.0.1:3306)/test?parseTime=true", "root:***@tcp(127.0.0.1:3306)/test?parseTime=true"},
+		{"root@tcp(127.0.0.1:3306)/test", "root@tcp(127.0.0.1:3306)/test"},
+		// 无法解析时按正则隐藏
+		{"root:secret@tcp(127.0.0.1:3306)", "root:***@tcp(127.0.0.1:3306)"},
+	}
+
+	for _, test := range tests {
+		result := RedactDSN(test.dsn)
+		if result != test.result {
+			t.Errorf("RedactDSN(%q)=%s, expected %s", test.dsn, result, test.result)
+		}
+	}
+}
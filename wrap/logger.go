@@ -0,0 +1,64 @@
+package wrap
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"log/slog"
+)
+
+// Logger 日志接口，keysAndValues为交替的键和值
+type Logger interface {
+	Enabled(level LogLevel) bool
+	Log(level LogLevel, msg string, keysAndValues ...interface{})
+}
+
+type zapLogger struct {
+	logger *zap.SugaredLogger
+}
+
+func NewZapLogger(logger *zap.Logger) Logger {
+	return &zapLogger{logger: logger.Sugar()}
+}
+
+func zapLevel(level LogLevel) zapcore.Level {
+	return zapcore.Level(level - 1)
+}
+
+func (l *zapLogger) Enabled(level LogLevel) bool {
+	return l.logger.Desugar().Core().Enabled(zapLevel(level))
+}
+
+func (l *zapLogger) Log(level LogLevel, msg string, keysAndValues ...interface{}) {
+	l.logger.Logw(zapLevel(level), msg, keysAndValues...)
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return &slogLogger{logger: logger}
+}
+
+func slogLevel(level LogLevel) slog.Level {
+	return slog.Level(4 * (int(level) - 1))
+}
+
+func (l *slogLogger) Enabled(level LogLevel) bool {
+	return l.logger.Enabled(context.Background(), slogLevel(level))
+}
+
+func (l *slogLogger) Log(level LogLevel, msg string, keysAndValues ...interface{}) {
+	l.logger.Log(context.Background(), slogLevel(level), msg, keysAndValues...)
+}
+
+// NopLogger 不输出任何日志
+type NopLogger struct{}
+
+func (NopLogger) Enabled(level LogLevel) bool {
+	return false
+}
+
+func (NopLogger) Log(level LogLevel, msg string, keysAndValues ...interface{}) {
+}
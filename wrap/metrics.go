@@ -0,0 +1,121 @@
+package wrap
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+type metrics struct {
+	duration     *prometheus.HistogramVec
+	errors       *prometheus.CounterVec
+	transactions *prometheus.CounterVec
+
+	reg        prometheus.Registerer
+	collectors []prometheus.Collector
+}
+
+// WithMetrics 在reg上注册语句耗时、错误、事务及连接池指标，name作为db标签区分同一进程中的多个DB。
+// 注册在Open成功后进行，重复注册等错误由Open返回
+func WithMetrics(reg prometheus.Registerer, name string) Option {
+	return func(db *DB) {
+		labels := prometheus.Labels{"db": name}
+		m := &metrics{
+			duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Name:        "sql_query_duration_seconds",
+				Help:        "Latency of database operations.",
+				ConstLabels: labels,
+				Buckets:     []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+			}, []string{"operation", "table", "method"}),
+			errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name:        "sql_errors_total",
+				Help:        "Database operation errors by class.",
+				ConstLabels: labels,
+			}, []string{"operation", "class"}),
+			transactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name:        "sql_transactions_total",
+				Help:        "Finished transactions by result.",
+				ConstLabels: labels,
+			}, []string{"result"}),
+			reg: reg,
+		}
+
+		// 读取时取值，注册时sql.DB已可用
+		counterFunc := func(name string, help string, f func() float64) prometheus.Collector {
+			return prometheus.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help, ConstLabels: labels}, f)
+		}
+		gaugeFunc := func(name string, help string, f func() float64) prometheus.Collector {
+			return prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help, ConstLabels: labels}, f)
+		}
+
+		m.collectors = append(m.collectors, m.duration, m.errors, m.transactions,
+			counterFunc("sql_transaction_retries_total", "Transaction retries.",
+				func() float64 { return float64(db.TxStats().Retries) }),
+			counterFunc("sql_transaction_retries_exhausted_total", "Transactions failed after all retries.",
+				func() float64 { return float64(db.TxStats().RetriesExhausted) }),
+			gaugeFunc("sql_pool_max_open_connections", "Maximum number of open connections.",
+				func() float64 { return float64(db.Stats().MaxOpenConnections) }),
+			gaugeFunc("sql_pool_open_connections", "Number of established connections.",
+				func() float64 { return float64(db.Stats().OpenConnections) }),
+			gaugeFunc("sql_pool_in_use_connections", "Number of connections currently in use.",
+				func() float64 { return float64(db.Stats().InUse) }),
+			gaugeFunc("sql_pool_idle_connections", "Number of idle connections.",
+				func() float64 { return float64(db.Stats().Idle) }),
+			counterFunc("sql_pool_wait_count_total", "Total number of connections waited for.",
+				func() float64 { return float64(db.Stats().WaitCount) }),
+			counterFunc("sql_pool_wait_duration_seconds_total", "Total time blocked waiting for a new connection.",
+				func() float64 { return db.Stats().WaitDuration.Seconds() }),
+			gaugeFunc("sql_stmt_cache_size", "Number of cached prepared statements.",
+				func() float64 { return float64(db.StmtCacheStats().Size) }),
+			counterFunc("sql_stmt_cache_hits_total", "Prepared statement cache hits.",
+				func() float64 { return float64(db.StmtCacheStats().Hits) }),
+			counterFunc("sql_stmt_cache_misses_total", "Prepared statement cache misses.",
+				func() float64 { return float64(db.StmtCacheStats().Misses) }),
+			counterFunc("sql_stmt_cache_evictions_total", "Prepared statements evicted from the cache.",
+				func() float64 { return float64(db.StmtCacheStats().Evictions) }),
+		)
+
+		db.metrics = m
+		db.interceptors = append(db.interceptors, m.interceptor)
+	}
+}
+
+// register 失败时注销已注册的指标，避免重试Open时重复注册
+func (m *metrics) register() error {
+	for i, c := range m.collectors {
+		err := m.reg.Register(c)
+		if err != nil {
+			for _, registered := range m.collectors[:i] {
+				m.reg.Unregister(registered)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *metrics) interceptor(ctx context.Context, call *Call, next Handler) error {
+	start := time.Now()
+	err := next(ctx, call)
+	m.duration.WithLabelValues(call.Op, TableFromContext(ctx), MethodFromContext(ctx)).
+		Observe(time.Since(start).Seconds())
+
+	if err != nil && !errors.Is(err, ErrNoRows) {
+		m.errors.WithLabelValues(call.Op, ErrorClass(ErrorWrap(err))).Inc()
+	}
+
+	switch call.Op {
+	case "Commit":
+		if err == nil {
+			m.transactions.WithLabelValues("commit").Inc()
+		} else {
+			m.transactions.WithLabelValues("commit_failed").Inc()
+		}
+	case "Rollback":
+		m.transactions.WithLabelValues("rollback").Inc()
+	}
+
+	return err
+}
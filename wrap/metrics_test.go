@@ -0,0 +1,92 @@
+package wrap
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	db, mock := newMockDB(t, WithMetrics(reg, "main"), WithMaxOpenConns(5))
+	ctx := WithTable(context.Background(), "user")
+
+	mock.ExpectExec("INSERT INTO user(name) VALUES (?)").WithArgs("a").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO user(name) VALUES (?)").WithArgs("a").
+		WillReturnError(&mysql.MySQLError{Number: 1062})
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	for i := 0; i < 2; i++ {
+		db.Exec(ctx, "INSERT INTO user(name) VALUES (?)", "a")
+	}
+	db.Transaction(context.Background(), nil, func(tx *Tx) error {
+		return nil
+	})
+	db.Transaction(context.Background(), nil, func(tx *Tx) error {
+		return errors.New("failed")
+	})
+
+	err := mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP sql_errors_total Database operation errors by class.
+# TYPE sql_errors_total counter
+sql_errors_total{class="duplicated",db="main",operation="Exec"} 1
+# HELP sql_pool_max_open_connections Maximum number of open connections.
+# TYPE sql_pool_max_open_connections gauge
+sql_pool_max_open_connections{db="main"} 5
+# HELP sql_transactions_total Finished transactions by result.
+# TYPE sql_transactions_total counter
+sql_transactions_total{db="main",result="commit"} 1
+sql_transactions_total{db="main",result="rollback"} 1
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"sql_errors_total", "sql_pool_max_open_connections", "sql_transactions_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := testutil.GatherAndCount(reg, "sql_query_duration_seconds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Exec、Begin、Commit、Rollback
+	if n != 4 {
+		t.Fatalf("series=%d", n)
+	}
+}
+
+func TestMetricsRegisterError(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	newMockDB(t, WithMetrics(reg, "main"))
+
+	// 同名DB重复注册时由Open返回错误
+	dsn, _ := newMockDSN(t)
+	_, err := Open("sqlmock", dsn, WithMetrics(reg, "main"))
+	var are prometheus.AlreadyRegisteredError
+	if !errors.As(err, &are) {
+		t.Fatalf("err=%v", err)
+	}
+
+	// sql.Open失败时不注册
+	other := prometheus.NewRegistry()
+	_, err = Open("unknown", "", WithMetrics(other, "main"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	families, err := other.Gather()
+	if err != nil || len(families) != 0 {
+		t.Fatalf("families=%d err=%v", len(families), err)
+	}
+}
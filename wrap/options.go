@@ -0,0 +1,92 @@
+package wrap
+
+import (
+	"context"
+	"database/sql"
+	"go.uber.org/zap"
+	"log/slog"
+	"time"
+)
+
+type Option func(db *DB)
+
+func WithLogger(logger Logger) Option {
+	return func(db *DB) {
+		db.logger = logger
+	}
+}
+
+func WithZapLogger(logger *zap.Logger) Option {
+	return WithLogger(NewZapLogger(logger))
+}
+
+func WithSlogLogger(logger *slog.Logger) Option {
+	return WithLogger(NewSlogLogger(logger))
+}
+
+func WithLogConfig(c *LogConfig) Option {
+	return func(db *DB) {
+		db.logConfig = c
+	}
+}
+
+func WithClock(now func() time.Time) Option {
+	return func(db *DB) {
+		db.now = now
+	}
+}
+
+func WithMaxOpenConns(n int) Option {
+	return func(db *DB) {
+		db.poolSettings = append(db.poolSettings, func(sqlDB *sql.DB) { sqlDB.SetMaxOpenConns(n) })
+	}
+}
+
+func WithMaxIdleConns(n int) Option {
+	return func(db *DB) {
+		db.poolSettings = append(db.poolSettings, func(sqlDB *sql.DB) { sqlDB.SetMaxIdleConns(n) })
+	}
+}
+
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(db *DB) {
+		db.poolSettings = append(db.poolSettings, func(sqlDB *sql.DB) { sqlDB.SetConnMaxLifetime(d) })
+	}
+}
+
+func WithConnMaxIdleTime(d time.Duration) Option {
+	return func(db *DB) {
+		db.poolSettings = append(db.poolSettings, func(sqlDB *sql.DB) { sqlDB.SetConnMaxIdleTime(d) })
+	}
+}
+
+// WithQueryTimeout ctx未设置截止时间时每条语句的默认超时
+func WithQueryTimeout(d time.Duration) Option {
+	return func(db *DB) {
+		db.queryTimeout = d
+	}
+}
+
+// Hook 在每次操作前后调用，op为Call.Name，Before返回的ctx传给After
+type Hook interface {
+	Before(ctx context.Context, op string, query string, args []interface{}) context.Context
+	After(ctx context.Context, op string, query string, args []interface{}, err error)
+}
+
+func WithHook(hook Hook) Option {
+	return func(db *DB) {
+		db.interceptors = append(db.interceptors, hookInterceptor(hook))
+	}
+}
+
+func (db *DB) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, db.queryTimeout)
+}
@@ -0,0 +1,191 @@
+package wrap
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrReplicationStopped = ErrorWrap(fmt.Errorf("sql: replication not running"))
+
+// ReplicaProbe 探测从库，返回复制延迟，返回错误时计为一次失败
+type ReplicaProbe interface {
+	Probe(ctx context.Context, name string, db *sql.DB) (lag time.Duration, err error)
+}
+
+// PingProbe 仅检查连接，不检查复制延迟
+type PingProbe struct{}
+
+func (PingProbe) Probe(ctx context.Context, name string, db *sql.DB) (time.Duration, error) {
+	return 0, db.PingContext(ctx)
+}
+
+// ReplicaStatusProbe 由SHOW REPLICA STATUS的Seconds_Behind_Source取得延迟，
+// MySQL 8.0.22以下回退到SHOW SLAVE STATUS
+type ReplicaStatusProbe struct{}
+
+func (ReplicaStatusProbe) Probe(ctx context.Context, name string, db *sql.DB) (time.Duration, error) {
+	lag, err := replicaStatusLag(ctx, db, "SHOW REPLICA STATUS", "Seconds_Behind_Source")
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1064 {
+		lag, err = replicaStatusLag(ctx, db, "SHOW SLAVE STATUS", "Seconds_Behind_Master")
+	}
+
+	return lag, err
+}
+
+func replicaStatusLag(ctx context.Context, db *sql.DB, query string, column string) (time.Duration, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, ErrReplicationStopped
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	err = rows.Scan(dest...)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, c := range columns {
+		if !strings.EqualFold(c, column) {
+			continue
+		}
+		// 复制线程未运行时为NULL
+		if values[i] == nil {
+			return 0, ErrReplicationStopped
+		}
+		seconds, err := strconv.ParseInt(string(values[i]), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	return 0, fmt.Errorf("sql: %s has no column %s", query, column)
+}
+
+// HeartbeatProbe 读取主库定期写入的心跳时间，延迟为当前时间与心跳时间之差，
+// 如pt-heartbeat的SELECT MAX(ts) FROM heartbeat
+type HeartbeatProbe struct {
+	Query string
+	Now   func() time.Time // 为nil时为time.Now
+}
+
+func (p HeartbeatProbe) Probe(ctx context.Context, name string, db *sql.DB) (time.Duration, error) {
+	var ts time.Time
+	err := db.QueryRowContext(ctx, p.Query).Scan(&ts)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	lag := now().Sub(ts)
+	if lag < 0 {
+		lag = 0
+	}
+
+	return lag, nil
+}
+
+// ReplicaState 从库最近一次探测的结果
+type ReplicaState struct {
+	Name                string
+	Healthy             bool
+	Lag                 time.Duration
+	LastProbe           time.Time
+	LastError           error
+	ConsecutiveFailures int
+}
+
+// ReplicaStates 各从库状态，未配置从库时为空
+func (db *DB) ReplicaStates() []ReplicaState {
+	if db.replicas == nil {
+		return nil
+	}
+
+	states := make([]ReplicaState, len(db.replicas.replicas))
+	for i, r := range db.replicas.replicas {
+		r.mutex.Lock()
+		states[i] = r.state
+		r.mutex.Unlock()
+	}
+
+	return states
+}
+
+// ProbeReplicas 并发探测所有从库并更新健康状态，后台按HealthCheckInterval调用
+func (db *DB) ProbeReplicas(ctx context.Context) {
+	if db.replicas == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, r := range db.replicas.replicas {
+		wg.Add(1)
+		go func(r *replica) {
+			defer wg.Done()
+			db.probeReplica(ctx, r)
+		}(r)
+	}
+	wg.Wait()
+}
+
+func (db *DB) probeReplica(ctx context.Context, r *replica) {
+	config := db.replicas.config
+	ctx, cancel := context.WithTimeout(ctx, config.HealthCheckInterval)
+	lag, err := config.Probe.Probe(ctx, r.name, r.db)
+	cancel()
+	// 延迟超过时立即视为不健康，FailureThreshold仅用于探测失败
+	lagging := err == nil && config.MaxLag > 0 && lag > config.MaxLag
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	state := &r.state
+	state.LastProbe = db.now()
+	state.Lag = lag
+	state.LastError = err
+	if err != nil {
+		state.ConsecutiveFailures++
+	} else {
+		state.ConsecutiveFailures = 0
+	}
+
+	healthy := state.ConsecutiveFailures < config.FailureThreshold && !lagging
+	if healthy == state.Healthy {
+		return
+	}
+	state.Healthy = healthy
+	r.healthy.Store(healthy)
+	if healthy {
+		db.logger.Log(LogInfo, "replica healthy", "replica", r.name, "lag", lag)
+	} else {
+		db.logger.Log(LogWarn, "replica unhealthy", "replica", r.name, "lag", lag,
+			"failures", state.ConsecutiveFailures, "error", err)
+	}
+}
@@ -0,0 +1,191 @@
+package wrap
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"sync"
+	"testing"
+	"time"
+)
+
+type probeResult struct {
+	lag time.Duration
+	err error
+}
+
+// fakeProbe 按从库名返回设定的结果，未设定时健康且无延迟
+type fakeProbe struct {
+	mutex   sync.Mutex
+	results map[string]probeResult
+}
+
+func (p *fakeProbe) set(name string, lag time.Duration, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.results[name] = probeResult{lag: lag, err: err}
+}
+
+func (p *fakeProbe) Probe(ctx context.Context, name string, db *sql.DB) (time.Duration, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	r := p.results[name]
+	return r.lag, r.err
+}
+
+// waitInitialProbe 等待后台启动时的首次探测完成
+func waitInitialProbe(t *testing.T, db *DB) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		probed := true
+		for _, s := range db.ReplicaStates() {
+			if s.LastProbe.IsZero() {
+				probed = false
+			}
+		}
+		if probed {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatal("initial probe not finished")
+}
+
+func expectSelect(mock sqlmock.Sqlmock, value string) {
+	mock.ExpectQuery("SELECT name FROM user").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(value))
+}
+
+func selectName(t *testing.T, db *DB) string {
+	t.Helper()
+
+	var name string
+	err := db.QueryRow(context.Background(), "SELECT name FROM user").Scan(&name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestReplicaProbe(t *testing.T) {
+	dsn0, replica0 := newMockDSN(t)
+	dsn1, replica1 := newMockDSN(t)
+	probe := &fakeProbe{results: map[string]probeResult{}}
+	db, primary := newMockDB(t, WithReplicas(ReplicaConfig{
+		DataSourceNames:     []string{dsn0, dsn1},
+		HealthCheckInterval: time.Hour,
+		Probe:               probe,
+		MaxLag:              time.Second,
+		FailureThreshold:    2,
+	}))
+	waitInitialProbe(t, db)
+	ctx := context.Background()
+	states := db.ReplicaStates()
+	name0, name1 := states[0].Name, states[1].Name
+
+	// 未达到FailureThreshold时仍然健康
+	failed := errors.New("probe failed")
+	probe.set(name0, 0, failed)
+	db.ProbeReplicas(ctx)
+	states = db.ReplicaStates()
+	if !states[0].Healthy || states[0].ConsecutiveFailures != 1 || states[0].LastError != failed {
+		t.Fatalf("state=%+v", states[0])
+	}
+
+	db.ProbeReplicas(ctx)
+	states = db.ReplicaStates()
+	if states[0].Healthy || states[0].ConsecutiveFailures != 2 || !states[1].Healthy {
+		t.Fatalf("states=%+v", states)
+	}
+
+	// 不健康的从库不再被选中
+	expectSelect(replica1, "replica1")
+	expectSelect(replica1, "replica1")
+	for i := 0; i < 2; i++ {
+		if name := selectName(t, db); name != "replica1" {
+			t.Fatalf("name=%s", name)
+		}
+	}
+
+	// 延迟超过MaxLag时立即不健康，所有从库不健康时走主库
+	probe.set(name1, 5*time.Second, nil)
+	db.ProbeReplicas(ctx)
+	states = db.ReplicaStates()
+	if states[1].Healthy || states[1].Lag != 5*time.Second || states[1].ConsecutiveFailures != 0 {
+		t.Fatalf("state=%+v", states[1])
+	}
+	expectSelect(primary, "primary")
+	if name := selectName(t, db); name != "primary" {
+		t.Fatalf("name=%s", name)
+	}
+
+	// 探测成功后恢复
+	probe.set(name0, 0, nil)
+	db.ProbeReplicas(ctx)
+	states = db.ReplicaStates()
+	if !states[0].Healthy || states[0].ConsecutiveFailures != 0 || states[0].LastError != nil || states[1].Healthy {
+		t.Fatalf("states=%+v", states)
+	}
+	expectSelect(replica0, "replica0")
+	if name := selectName(t, db); name != "replica0" {
+		t.Fatalf("name=%s", name)
+	}
+
+	probe.set(name1, 500*time.Millisecond, nil)
+	db.ProbeReplicas(ctx)
+	for _, s := range db.ReplicaStates() {
+		if !s.Healthy {
+			t.Fatalf("state=%+v", s)
+		}
+	}
+
+	for _, m := range []sqlmock.Sqlmock{primary, replica0, replica1} {
+		err := m.ExpectationsWereMet()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReplicaStatusProbe(t *testing.T) {
+	dsn, mock := newMockDSN(t)
+	db, err := sql.Open("sqlmock", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mock.ExpectQuery("SHOW REPLICA STATUS").
+		WillReturnRows(sqlmock.NewRows([]string{"Replica_IO_State", "Seconds_Behind_Source"}).AddRow("Waiting", "3"))
+	lag, err := ReplicaStatusProbe{}.Probe(ctx, "r", db)
+	if err != nil || lag != 3*time.Second {
+		t.Fatalf("lag=%s err=%v", lag, err)
+	}
+
+	// 复制线程未运行
+	mock.ExpectQuery("SHOW REPLICA STATUS").
+		WillReturnRows(sqlmock.NewRows([]string{"Replica_IO_State", "Seconds_Behind_Source"}).AddRow("", nil))
+	_, err = ReplicaStatusProbe{}.Probe(ctx, "r", db)
+	if err != ErrReplicationStopped {
+		t.Fatalf("err=%v", err)
+	}
+
+	// 低版本回退到SHOW SLAVE STATUS
+	mock.ExpectQuery("SHOW REPLICA STATUS").WillReturnError(&mysql.MySQLError{Number: 1064})
+	mock.ExpectQuery("SHOW SLAVE STATUS").
+		WillReturnRows(sqlmock.NewRows([]string{"Slave_IO_State", "Seconds_Behind_Master"}).AddRow("Waiting", "1"))
+	lag, err = ReplicaStatusProbe{}.Probe(ctx, "r", db)
+	if err != nil || lag != time.Second {
+		t.Fatalf("lag=%s err=%v", lag, err)
+	}
+
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
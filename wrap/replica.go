@@ -0,0 +1,210 @@
+package wrap
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type ReplicaPolicy int
+
+const (
+	ReplicaRoundRobin ReplicaPolicy = iota
+	ReplicaLeastConns               // 选择使用中连接最少的从库
+)
+
+// ReplicaConfig 从库配置，从库与主库使用同一驱动和连接池设置
+type ReplicaConfig struct {
+	DataSourceNames     []string
+	Policy              ReplicaPolicy
+	HealthCheckInterval time.Duration // 为0时为5秒
+	// WithReadYourWrites的ctx写入后，此时间内的读取走主库，为0时为1秒
+	ReadYourWritesWindow time.Duration
+	Probe                ReplicaProbe  // 为nil时为PingProbe
+	MaxLag               time.Duration // 复制延迟超过时视为不健康，为0时不检查
+	FailureThreshold     int           // 连续探测失败次数达到后视为不健康，为0时为1
+}
+
+// WithReplicas 事务外的Query、QueryRow路由到健康的从库，无健康从库时走主库。
+// Exec和事务始终走主库
+func WithReplicas(config ReplicaConfig) Option {
+	return func(db *DB) {
+		if config.HealthCheckInterval <= 0 {
+			config.HealthCheckInterval = 5 * time.Second
+		}
+		if config.ReadYourWritesWindow <= 0 {
+			config.ReadYourWritesWindow = time.Second
+		}
+		if config.Probe == nil {
+			config.Probe = PingProbe{}
+		}
+		if config.FailureThreshold <= 0 {
+			config.FailureThreshold = 1
+		}
+		db.replicas = &replicaSet{config: config}
+	}
+}
+
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+
+	mutex sync.Mutex
+	state ReplicaState
+}
+
+type replicaSet struct {
+	config   ReplicaConfig
+	replicas []*replica
+	next     atomic.Uint64
+	stop     chan struct{}
+	stopOnce sync.Once // DB.Close可重复调用
+	wg       sync.WaitGroup
+}
+
+type primaryContextKey struct{}
+
+type sessionContextKey struct{}
+
+type session struct {
+	lastWrite atomic.Int64 // monotonicNow
+}
+
+// WithPrimary ctx中的读取走主库
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+// WithReadYourWrites ctx及其派生ctx中的写入和事务提交后，
+// ReadYourWritesWindow内的读取走主库。通常每个请求调用一次
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, &session{})
+}
+
+func (db *DB) openReplicas(driverName string) error {
+	rs := db.replicas
+	for _, dsn := range rs.config.DataSourceNames {
+		db.logger.Log(LogInfo, "Open replica", "driverName", driverName, "dataSourceName", RedactDSN(dsn))
+		sqlDB, err := sql.Open(driverName, dsn)
+		if err != nil {
+			db.logger.Log(LogError, "Open replica", "error", err)
+			rs.close()
+			return ErrorWrap(err)
+		}
+		for _, f := range db.poolSettings {
+			f(sqlDB)
+		}
+
+		r := &replica{name: RedactDSN(dsn), db: sqlDB}
+		r.healthy.Store(true)
+		r.state = ReplicaState{Name: r.name, Healthy: true}
+		rs.replicas = append(rs.replicas, r)
+	}
+
+	rs.stop = make(chan struct{})
+	rs.wg.Add(1)
+	go db.healthCheck()
+
+	return nil
+}
+
+// healthCheck 启动时立即探测一次，之后按HealthCheckInterval探测
+func (db *DB) healthCheck() {
+	rs := db.replicas
+	defer rs.wg.Done()
+
+	ticker := time.NewTicker(rs.config.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		db.ProbeReplicas(context.Background())
+		select {
+		case <-rs.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reader 选择执行事务外Query的连接池，INSERT ... RETURNING等写入走主库
+func (db *DB) reader(ctx context.Context, query string) *sql.DB {
+	rs := db.replicas
+	if rs == nil || len(rs.replicas) == 0 || ctx.Value(primaryContextKey{}) != nil {
+		return db.db
+	}
+
+	switch queryOperation(query) {
+	case "SELECT", "WITH", "SHOW", "EXPLAIN", "DESC", "DESCRIBE":
+	default:
+		db.markWrite(ctx)
+		return db.db
+	}
+
+	if s, _ := ctx.Value(sessionContextKey{}).(*session); s != nil {
+		if last := s.lastWrite.Load(); last > 0 &&
+			monotonicNow()-time.Duration(last) < rs.config.ReadYourWritesWindow {
+			return db.db
+		}
+	}
+
+	var selected *replica
+	switch rs.config.Policy {
+	case ReplicaLeastConns:
+		inUse := 0
+		for _, r := range rs.replicas {
+			if !r.healthy.Load() {
+				continue
+			}
+			if n := r.db.Stats().InUse; selected == nil || n < inUse {
+				selected, inUse = r, n
+			}
+		}
+	default:
+		start := rs.next.Add(1)
+		for i := range rs.replicas {
+			r := rs.replicas[(start+uint64(i))%uint64(len(rs.replicas))]
+			if r.healthy.Load() {
+				selected = r
+				break
+			}
+		}
+	}
+
+	if selected == nil {
+		return db.db
+	}
+	return selected.db
+}
+
+// markWrite 记录WithReadYourWrites的ctx中的写入时间
+func (db *DB) markWrite(ctx context.Context) {
+	if s, _ := ctx.Value(sessionContextKey{}).(*session); s != nil {
+		s.lastWrite.Store(int64(monotonicNow()))
+	}
+}
+
+var monotonicStart = time.Now()
+
+// monotonicNow 读写一致窗口使用单调时钟，不受WithClock和系统时间调整影响
+func monotonicNow() time.Duration {
+	return time.Since(monotonicStart)
+}
+
+func (rs *replicaSet) close() error {
+	if rs.stop != nil {
+		rs.stopOnce.Do(func() {
+			close(rs.stop)
+		})
+		rs.wg.Wait()
+	}
+
+	var err error
+	for _, r := range rs.replicas {
+		if e := r.db.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
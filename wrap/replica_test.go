@@ -0,0 +1,70 @@
+package wrap
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"testing"
+	"time"
+)
+
+// 读写一致窗口不受WithClock影响，时钟固定时窗口仍会过期
+func TestReadYourWrites(t *testing.T) {
+	replicaDSN, replica := newMockDSN(t)
+	clock := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	db, primary := newMockDB(t,
+		WithClock(func() time.Time { return clock }),
+		WithReplicas(ReplicaConfig{DataSourceNames: []string{replicaDSN}, ReadYourWritesWindow: 50 * time.Millisecond}))
+	ctx := WithReadYourWrites(context.Background())
+
+	primary.ExpectExec("UPDATE user SET name=? WHERE id=?").WithArgs("a", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	primary.ExpectQuery("SELECT name FROM user WHERE id=?").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("a"))
+	replica.ExpectQuery("SELECT name FROM user WHERE id=?").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("a"))
+
+	_, err := db.Exec(ctx, "UPDATE user SET name=? WHERE id=?", "a", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var name string
+	err = db.QueryRow(ctx, "SELECT name FROM user WHERE id=?", 1).Scan(&name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = primary.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	err = db.QueryRow(ctx, "SELECT name FROM user WHERE id=?", 1).Scan(&name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = replica.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplicaDoubleClose(t *testing.T) {
+	replicaDSN, _ := newMockDSN(t)
+	dsn, _ := newMockDSN(t)
+	db, err := Open("sqlmock", dsn, WithReplicas(ReplicaConfig{
+		DataSourceNames:     []string{replicaDSN},
+		HealthCheckInterval: time.Hour,
+		Probe:               &fakeProbe{results: map[string]probeResult{}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitInitialProbe(t, db)
+
+	for i := 0; i < 2; i++ {
+		err = db.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package wrap
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy 事务重试策略，仅适用于可重复执行的事务函数
+type RetryPolicy struct {
+	MaxAttempts    int // 包含首次执行
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64              // 退避时间随机浮动比例，取值0~1
+	Retryable      func(err error) bool // 为nil时使用IsRetryable
+}
+
+var DefaultRetryPolicy = &RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 10 * time.Millisecond,
+	MaxBackoff:     time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// IsRetryable 死锁(1213)和锁等待超时(1205)可重试
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// 重新分类，兼容事务函数直接返回的驱动错误
+	e := ErrorWrap(err)
+	return errors.Is(e, ErrDeadlock) || errors.Is(e, ErrLockWaitTimeout)
+}
+
+// 第attempt次失败后的退避时间
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.Multiplier
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d *= 1 + p.Jitter*(rand.Float64()*2-1)
+	}
+
+	return time.Duration(d)
+}
+
+type TxStats struct {
+	Retries          int64 // 重试次数
+	RetriesExhausted int64 // 重试次数用尽后仍失败的事务数
+}
+
+type txStats struct {
+	retries          atomic.Int64
+	retriesExhausted atomic.Int64
+}
+
+func (db *DB) TxStats() TxStats {
+	return TxStats{
+		Retries:          db.txStats.retries.Load(),
+		RetriesExhausted: db.txStats.retriesExhausted.Load(),
+	}
+}
+
+func (db *DB) retry(ctx context.Context, p *RetryPolicy, f func() error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || !retryable(err) {
+			return err
+		}
+
+		if attempt >= p.MaxAttempts {
+			db.txStats.retriesExhausted.Add(1)
+			db.logger.Log(LogError, "transaction retries exhausted", "attempts", attempt, "error", err)
+			return err
+		}
+
+		backoff := p.backoff(attempt)
+		db.txStats.retries.Add(1)
+		db.logger.Log(LogWarn, "transaction retry",
+			"attempt", attempt, "backoff", backoff, "error", err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
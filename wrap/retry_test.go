@@ -0,0 +1,80 @@
+package wrap
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"testing"
+	"time"
+)
+
+func TestTransactionRetry(t *testing.T) {
+	db, mock := newMockDB(t)
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 2}
+
+	// 前两次死锁，第三次成功
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE t SET a=1").WillReturnError(deadlock)
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE t SET a=1").WillReturnError(deadlock)
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE t SET a=1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	attempts := 0
+	update := func(tx *Tx) error {
+		attempts++
+		_, err := tx.Exec(tx.Context(), "UPDATE t SET a=1")
+		return err
+	}
+	err := db.Transaction(context.Background(), &TxOptions{Retry: policy}, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts=%d", attempts)
+	}
+	if stats := db.TxStats(); stats != (TxStats{Retries: 2}) {
+		t.Fatalf("stats=%+v", stats)
+	}
+
+	// 重试次数用尽
+	for i := 0; i < 3; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE t SET a=1").WillReturnError(deadlock)
+		mock.ExpectRollback()
+	}
+	attempts = 0
+	err = db.Transaction(context.Background(), &TxOptions{Retry: policy}, update)
+	if !errors.Is(err, ErrDeadlock) || ErrorClass(err) != "deadlock" {
+		t.Fatalf("err=%v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts=%d", attempts)
+	}
+	if stats := db.TxStats(); stats != (TxStats{Retries: 4, RetriesExhausted: 1}) {
+		t.Fatalf("stats=%+v", stats)
+	}
+
+	// 不可重试的错误只执行一次
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE t SET a=1").WillReturnError(&mysql.MySQLError{Number: 1062})
+	mock.ExpectRollback()
+	attempts = 0
+	err = db.Transaction(context.Background(), &TxOptions{Retry: policy}, update)
+	if !errors.Is(err, ErrDuplicated) || attempts != 1 {
+		t.Fatalf("attempts=%d err=%v", attempts, err)
+	}
+	if stats := db.TxStats(); stats != (TxStats{Retries: 4, RetriesExhausted: 1}) {
+		t.Fatalf("stats=%+v", stats)
+	}
+
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
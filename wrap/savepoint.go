@@ -0,0 +1,59 @@
+package wrap
+
+import (
+	"context"
+	"fmt"
+)
+
+// Transaction 在当前事务中以SAVEPOINT开启嵌套事务，f返回错误时回滚到保存点，不影响外层事务
+func (tx *Tx) Transaction(ctx context.Context, f func(tx *Tx) (err error)) (err error) {
+	root := tx
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.savepointId++
+
+	child := &Tx{db: tx.db, tx: tx.tx, parent: tx, savepoint: fmt.Sprintf("sp_%d", root.savepointId)}
+	child.ctx = NewTxContext(ctx, child)
+
+	_, err = tx.db.exec(ctx, tx.tx, "Tx.Savepoint", "SAVEPOINT "+child.savepoint)
+	if err != nil {
+		return err
+	}
+
+	// 回滚到保存点并执行保存点内注册的OnRollback后继续抛出panic
+	defer func() {
+		if p := recover(); p != nil {
+			tx.db.logger.Log(LogError, "savepoint panic", "savepoint", child.savepoint, "panic", p)
+			_, rollbackErr := tx.db.exec(ctx, tx.tx, "Tx.RollbackToSavepoint", "ROLLBACK TO SAVEPOINT "+child.savepoint)
+			if rollbackErr != nil {
+				tx.db.logger.Log(LogError, "RollbackToSavepoint", "error", rollbackErr)
+			}
+			child.runRollbackHooks(fmt.Errorf("panic: %v", p))
+			panic(p)
+		}
+	}()
+
+	err = f(child)
+	if err != nil {
+		tx.db.logger.Log(LogInfo, "savepoint exec failed", "savepoint", child.savepoint, "error", err)
+		_, rollbackErr := tx.db.exec(ctx, tx.tx, "Tx.RollbackToSavepoint", "ROLLBACK TO SAVEPOINT "+child.savepoint)
+		if rollbackErr != nil {
+			tx.db.logger.Log(LogError, "RollbackToSavepoint", "error", rollbackErr)
+		}
+		err = ErrorWrap(err)
+		child.runRollbackHooks(err)
+		return err
+	}
+
+	_, err = tx.db.exec(ctx, tx.tx, "Tx.ReleaseSavepoint", "RELEASE SAVEPOINT "+child.savepoint)
+	if err != nil {
+		child.runRollbackHooks(err)
+		return err
+	}
+
+	// 是否生效取决于外层事务
+	tx.mergeHooks(child)
+
+	return nil
+}
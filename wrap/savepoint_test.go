@@ -0,0 +1,67 @@
+package wrap
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"testing"
+)
+
+func TestSavepointRollback(t *testing.T) {
+	db, mock := newMockDB(t)
+	failed := errors.New("failed")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE t SET a=1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SAVEPOINT sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE t SET a=2").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	// 同一事务中保存点编号不重复
+	mock.ExpectExec("SAVEPOINT sp_3").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_3").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := db.Transaction(context.Background(), nil, func(tx *Tx) error {
+		err := tx.Transaction(tx.Context(), func(tx *Tx) error {
+			_, err := tx.Exec(tx.Context(), "UPDATE t SET a=1")
+			if err != nil {
+				return err
+			}
+
+			// 内层保存点失败只回滚自身
+			err = tx.Transaction(tx.Context(), func(tx *Tx) error {
+				_, err := tx.Exec(tx.Context(), "UPDATE t SET a=2")
+				if err != nil {
+					return err
+				}
+				return failed
+			})
+			if !errors.Is(err, failed) {
+				t.Fatalf("err=%v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// ctx中已有事务时DB.Transaction以保存点嵌套执行
+		err = db.Transaction(tx.Context(), nil, func(tx *Tx) error {
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Fatalf("err=%v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,219 @@
+package wrap
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+var ErrMissingShardKey = ErrorWrap(fmt.Errorf("sql: missing shard key"))
+
+var ErrCrossShard = ErrorWrap(fmt.Errorf("sql: statement spans multiple shards"))
+
+// ShardRouter 返回分片键值所在分片的序号
+type ShardRouter func(key interface{}) (int, error)
+
+// Sharding 表的分片规则，Column为分片键列名
+type Sharding struct {
+	Column string
+	Shards []*DB
+	Router ShardRouter
+}
+
+// HashShardRouter 按分片键值的FNV哈希取模，键值按driver.Valuer取值后计算，
+// sql.NullInt64和int64等同值的键落在同一分片。n小于等于0时panic
+func HashShardRouter(n int) ShardRouter {
+	if n <= 0 {
+		panic(fmt.Sprintf("sql: invalid shard count %d", n))
+	}
+
+	return func(key interface{}) (int, error) {
+		if valuer, ok := key.(driver.Valuer); ok {
+			var err error
+			key, err = valuer.Value()
+			if err != nil {
+				return 0, err
+			}
+		}
+		key = compareValue(key)
+		if b, ok := key.([]byte); ok {
+			key = string(b)
+		}
+
+		h := fnv.New32a()
+		fmt.Fprint(h, key)
+		return int(h.Sum32() % uint32(n)), nil
+	}
+}
+
+// Shard 分片键值所在的分片
+func (s *Sharding) Shard(key interface{}) (*DB, error) {
+	i, err := s.Router(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if i < 0 || i >= len(s.Shards) {
+		return nil, fmt.Errorf("sql: shard %d out of range [0,%d)", i, len(s.Shards))
+	}
+
+	return s.Shards[i], nil
+}
+
+// Route 分片键值所在的分片，按分片序号排列且不重复
+func (s *Sharding) Route(keys []interface{}) ([]*DB, error) {
+	if len(keys) == 0 {
+		return nil, ErrMissingShardKey
+	}
+
+	selected := make([]bool, len(s.Shards))
+	for _, key := range keys {
+		i, err := s.Router(key)
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= len(s.Shards) {
+			return nil, fmt.Errorf("sql: shard %d out of range [0,%d)", i, len(s.Shards))
+		}
+		selected[i] = true
+	}
+
+	var shards []*DB
+	for i, ok := range selected {
+		if ok {
+			shards = append(shards, s.Shards[i])
+		}
+	}
+
+	return shards, nil
+}
+
+// FanOut 在各分片上并发执行f，任一分片失败时取消其余分片并返回首个错误。
+// ctx中绑定了事务时事务只属于一个分片，跨分片执行返回ErrCrossShard
+func FanOut(ctx context.Context, shards []*DB, f func(ctx context.Context, i int, db *DB) error) error {
+	if len(shards) == 1 {
+		return f(ctx, 0, shards[0])
+	}
+	if TxFromContext(ctx) != nil {
+		return ErrCrossShard
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var once sync.Once
+	var firstErr error
+	var wg sync.WaitGroup
+	for i, db := range shards {
+		wg.Add(1)
+		go func(i int, db *DB) {
+			defer wg.Done()
+			if err := f(ctx, i, db); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i, db)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+// CompareValues 跨分片合并排序时比较字段值，nil及无效的sql.Null*最小。
+// 支持整数、浮点、字符串、[]byte、bool、time.Time及driver.Valuer，类型不同时视为相等。
+// 字符串按字节比较，不遵循列的排序规则（如utf8mb4_general_ci不区分大小写），
+// 按字符串列跨分片排序时合并后的顺序可能与单库查询不同
+func CompareValues(a interface{}, b interface{}) int {
+	a, b = compareValue(a), compareValue(b)
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	switch x := a.(type) {
+	case int64:
+		if y, ok := b.(int64); ok {
+			return compareOrdered(x < y, x > y)
+		}
+	case uint64:
+		if y, ok := b.(uint64); ok {
+			return compareOrdered(x < y, x > y)
+		}
+	case float64:
+		if y, ok := b.(float64); ok {
+			return compareOrdered(x < y, x > y)
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return compareOrdered(x < y, x > y)
+		}
+	case []byte:
+		if y, ok := b.([]byte); ok {
+			return bytes.Compare(x, y)
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			return compareOrdered(!x && y, x && !y)
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return compareOrdered(x.Before(y), x.After(y))
+		}
+	}
+
+	return 0
+}
+
+func compareOrdered(less bool, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareValue(v interface{}) interface{} {
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		v, err = valuer.Value()
+		if err != nil {
+			return nil
+		}
+	}
+
+	switch x := v.(type) {
+	case int:
+		return int64(x)
+	case int8:
+		return int64(x)
+	case int16:
+		return int64(x)
+	case int32:
+		return int64(x)
+	case uint:
+		return uint64(x)
+	case uint8:
+		return uint64(x)
+	case uint16:
+		return uint64(x)
+	case uint32:
+		return uint64(x)
+	case float32:
+		return float64(x)
+	}
+
+	return v
+}
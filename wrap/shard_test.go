@@ -0,0 +1,94 @@
+package wrap
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestFanOutInTx(t *testing.T) {
+	db, mock := newMockDB(t)
+	other, _ := newMockDB(t)
+	shards := []*DB{db, other}
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	err := db.Transaction(context.Background(), nil, func(tx *Tx) error {
+		called := false
+		err := FanOut(tx.Context(), shards, func(ctx context.Context, i int, db *DB) error {
+			called = true
+			return nil
+		})
+		if err != ErrCrossShard || called {
+			t.Fatalf("err=%v called=%t", err, called)
+		}
+
+		// 单个分片在事务中执行
+		return FanOut(tx.Context(), shards[:1], func(ctx context.Context, i int, db *DB) error {
+			called = true
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		a, b     interface{}
+		expected int
+	}{
+		{int32(1), int64(2), -1},
+		{uint32(3), uint64(2), 1},
+		{float32(1.5), 1.5, 0},
+		{nil, 1, -1},
+		{sql.NullInt64{}, sql.NullInt64{Int64: 1, Valid: true}, -1},
+		{now, now.Add(time.Second), -1},
+		{"a", "b", -1},
+		// 按字节比较，不遵循排序规则
+		{"B", "a", -1},
+		{[]byte("b"), []byte("a"), 1},
+		{true, false, 1},
+		{"1", 1, 0},
+	}
+	for _, test := range tests {
+		if c := CompareValues(test.a, test.b); c != test.expected {
+			t.Errorf("CompareValues(%v,%v)=%d, expected %d", test.a, test.b, c, test.expected)
+		}
+	}
+}
+
+func TestHashShardRouter(t *testing.T) {
+	router := HashShardRouter(8)
+	same := [][]interface{}{
+		{int64(42), 42, int32(42), uint8(42), sql.NullInt64{Int64: 42, Valid: true}},
+		{"abc", []byte("abc"), sql.NullString{String: "abc", Valid: true}},
+	}
+	for _, keys := range same {
+		expected, err := router(keys[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range keys[1:] {
+			i, err := router(key)
+			if err != nil || i != expected {
+				t.Fatalf("key=%#v shard=%d expected=%d err=%v", key, i, expected, err)
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	HashShardRouter(0)
+}
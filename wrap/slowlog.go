@@ -0,0 +1,138 @@
+package wrap
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// SlowQueryConfig 慢查询日志配置
+type SlowQueryConfig struct {
+	Threshold time.Duration
+	// SELECT语句在结果集关闭后于执行语句的连接池（如从库）或事务中执行EXPLAIN FORMAT=JSON，
+	// 执行计划附加到日志中
+	Explain        bool
+	ExplainTimeout time.Duration // 为0时为1秒
+}
+
+type slowLog struct {
+	db     *DB
+	config SlowQueryConfig
+}
+
+// WithSlowQueryLog 以Warn级别记录耗时超过Threshold的语句
+func WithSlowQueryLog(config SlowQueryConfig) Option {
+	return func(db *DB) {
+		l := &slowLog{db: db, config: config}
+		if l.config.ExplainTimeout <= 0 {
+			l.config.ExplainTimeout = time.Second
+		}
+		db.interceptors = append(db.interceptors, l.interceptor)
+	}
+}
+
+func (l *slowLog) interceptor(ctx context.Context, call *Call, next Handler) error {
+	start := time.Now()
+	err := next(ctx, call)
+	elapsed := time.Since(start)
+	if call.Query == "" || elapsed < l.config.Threshold {
+		return err
+	}
+
+	keysAndValues := []interface{}{
+		"fingerprint", Fingerprint(call.Query),
+		"args", l.db.logArgs(call.Query, call.Args),
+		"elapsed", elapsed,
+		"op", call.Name,
+		"table", TableFromContext(ctx),
+		"method", MethodFromContext(ctx),
+	}
+	if err != nil {
+		keysAndValues = append(keysAndValues, "error", err)
+	}
+
+	if !l.config.Explain || err != nil || call.conn == nil ||
+		(call.Op != "Query" && call.Op != "QueryRow") || queryOperation(call.Query) != "SELECT" {
+		l.db.logger.Log(LogWarn, "slow query", keysAndValues...)
+		return err
+	}
+
+	// 结果集未关闭前连接不可用
+	c := call.conn
+	if sc, ok := c.(*stmtConn); ok {
+		c = sc.conn
+	}
+	query, args := call.Query, call.Args
+	call.AfterClose(func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), l.config.ExplainTimeout)
+		defer cancel()
+
+		plan, err := explain(ctx, c, query, args)
+		if err != nil {
+			keysAndValues = append(keysAndValues, "explain_error", err)
+		} else {
+			keysAndValues = append(keysAndValues,
+				"plan", plan.JSON,
+				"full_scan", plan.FullScanTables,
+				"filesort", plan.Filesort,
+				"temporary", plan.Temporary)
+		}
+		l.db.logger.Log(LogWarn, "slow query", keysAndValues...)
+	})
+
+	return err
+}
+
+// Plan EXPLAIN FORMAT=JSON的结果及其中值得关注的项
+type Plan struct {
+	JSON           string
+	FullScanTables []string // access_type为ALL的表
+	Filesort       bool
+	Temporary      bool
+}
+
+func explain(ctx context.Context, c conn, query string, args []interface{}) (*Plan, error) {
+	var data string
+	err := c.QueryRowContext(ctx, "EXPLAIN FORMAT=JSON "+query, args...).Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePlan(data)
+}
+
+// ParsePlan 解析EXPLAIN FORMAT=JSON的输出
+func ParsePlan(data string) (*Plan, error) {
+	var v interface{}
+	err := json.Unmarshal([]byte(data), &v)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{JSON: data}
+	plan.walk(v)
+	return plan, nil
+}
+
+func (p *Plan) walk(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if v["access_type"] == "ALL" {
+			table, _ := v["table_name"].(string)
+			p.FullScanTables = append(p.FullScanTables, table)
+		}
+		if v["using_filesort"] == true {
+			p.Filesort = true
+		}
+		if v["using_temporary_table"] == true {
+			p.Temporary = true
+		}
+		for _, child := range v {
+			p.walk(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			p.walk(child)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package wrap
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"sync"
+	"testing"
+	"time"
+)
+
+type logEntry struct {
+	level         LogLevel
+	msg           string
+	keysAndValues []interface{}
+}
+
+// recordLogger 记录所有日志
+type recordLogger struct {
+	mutex   sync.Mutex
+	entries []logEntry
+}
+
+func (l *recordLogger) Enabled(level LogLevel) bool {
+	return true
+}
+
+func (l *recordLogger) Log(level LogLevel, msg string, keysAndValues ...interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.entries = append(l.entries, logEntry{level: level, msg: msg, keysAndValues: keysAndValues})
+}
+
+// find 返回最后一条msg的日志中key的值
+func (l *recordLogger) find(msg string, key string) (interface{}, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		e := l.entries[i]
+		if e.msg != msg {
+			continue
+		}
+		for j := 0; j+1 < len(e.keysAndValues); j += 2 {
+			if e.keysAndValues[j] == key {
+				return e.keysAndValues[j+1], true
+			}
+		}
+		return nil, false
+	}
+
+	return nil, false
+}
+
+const testPlan = `{"query_block":{"table":{"table_name":"user","access_type":"ALL"}}}`
+
+// 从库上的慢查询在从库执行EXPLAIN
+func TestSlowQueryExplainReplica(t *testing.T) {
+	replicaDSN, replica := newMockDSN(t)
+	logger := &recordLogger{}
+	db, primary := newMockDB(t,
+		WithLogger(logger),
+		WithReplicas(ReplicaConfig{DataSourceNames: []string{replicaDSN}}),
+		WithSlowQueryLog(SlowQueryConfig{Explain: true}))
+
+	replica.ExpectQuery("SELECT id FROM user").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	replica.ExpectQuery("EXPLAIN FORMAT=JSON SELECT id FROM user").
+		WillReturnRows(sqlmock.NewRows([]string{"plan"}).AddRow(testPlan))
+
+	rows, err := db.Query(context.Background(), "SELECT id FROM user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+
+	fullScan, ok := logger.find("slow query", "full_scan")
+	if !ok {
+		t.Fatal("no plan logged")
+	}
+	if tables, _ := fullScan.([]string); len(tables) != 1 || tables[0] != "user" {
+		t.Fatalf("full_scan=%v", fullScan)
+	}
+
+	err = replica.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = primary.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 缓存或显式预编译的语句在语句所属的连接池或事务中执行EXPLAIN，而不是主库
+func TestSlowQueryExplainStmt(t *testing.T) {
+	replicaDSN, replica := newMockDSN(t)
+	replicaDB, err := sql.Open("sqlmock", replicaDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replicaDB.Close()
+
+	logger := &recordLogger{}
+	db, primary := newMockDB(t, WithLogger(logger))
+	l := &slowLog{db: db, config: SlowQueryConfig{Explain: true, ExplainTimeout: time.Second}}
+
+	replica.ExpectQuery("EXPLAIN FORMAT=JSON SELECT id FROM user WHERE id=?").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"plan"}).AddRow(testPlan))
+
+	call := &Call{Op: "Query", Query: "SELECT id FROM user WHERE id=?", Args: []interface{}{1},
+		conn: &stmtConn{conn: replicaDB}}
+	err = l.interceptor(context.Background(), call, func(ctx context.Context, call *Call) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	call.runAfterClose()
+
+	if _, ok := logger.find("slow query", "plan"); !ok {
+		t.Fatal("no plan logged")
+	}
+	err = replica.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = primary.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,201 @@
+package wrap
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StatementStats 按指纹聚合的语句统计，类似pg_stat_statements。
+// 扫描行数只能在服务端取得，这里记录返回行数和影响行数
+type StatementStats struct {
+	Fingerprint  string        `json:"fingerprint"`
+	Count        int64         `json:"count"`
+	Errors       int64         `json:"errors"`
+	TotalLatency time.Duration `json:"total_latency_ns"`
+	MaxLatency   time.Duration `json:"max_latency_ns"`
+	P99Latency   time.Duration `json:"p99_latency_ns"`
+	RowsReturned int64         `json:"rows_returned"`
+	RowsAffected int64         `json:"rows_affected"`
+}
+
+// 超过指纹数上限后的语句计入此项
+const otherFingerprint = "(other)"
+
+// 延迟按2^(1/8)倍划分桶，从1微秒起，p99的误差在9%以内
+const (
+	latencyBucketBase  = time.Microsecond
+	latencyBucketScale = 8
+	latencyBuckets     = 256
+)
+
+type statementEntry struct {
+	stats   StatementStats
+	buckets [latencyBuckets]uint32
+}
+
+type statementStatsTable struct {
+	mutex         sync.Mutex
+	maxStatements int
+	entries       map[string]*statementEntry
+	fingerprints  map[string]string // 语句到指纹的缓存
+}
+
+// WithStatementStats 记录每类语句的次数、延迟、行数和错误，maxStatements为指纹数上限，为0时为1000
+func WithStatementStats(maxStatements int) Option {
+	return func(db *DB) {
+		if maxStatements <= 0 {
+			maxStatements = 1000
+		}
+		db.statementStats = &statementStatsTable{
+			maxStatements: maxStatements,
+			entries:       map[string]*statementEntry{},
+			fingerprints:  map[string]string{},
+		}
+		db.interceptors = append(db.interceptors, db.statementStats.interceptor)
+	}
+}
+
+func (t *statementStatsTable) interceptor(ctx context.Context, call *Call, next Handler) error {
+	start := time.Now()
+	err := next(ctx, call)
+	elapsed := time.Since(start)
+
+	switch call.Op {
+	case "Exec":
+		var rowsAffected int64
+		if err == nil && call.Result != nil {
+			rowsAffected, _ = call.Result.RowsAffected()
+		}
+		t.record(call.Query, elapsed, err, 0, rowsAffected)
+	case "Query", "QueryRow":
+		// 结果集关闭后才能取得返回行数
+		call.AfterClose(func() {
+			t.record(call.Query, elapsed, err, call.RowsReturned, 0)
+		})
+	}
+
+	return err
+}
+
+func (t *statementStatsTable) record(query string, elapsed time.Duration, err error, rowsReturned int64, rowsAffected int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	fingerprint, ok := t.fingerprints[query]
+	if !ok {
+		fingerprint = Fingerprint(query)
+		if len(t.fingerprints) < t.maxStatements*10 {
+			t.fingerprints[query] = fingerprint
+		}
+	}
+
+	entry := t.entries[fingerprint]
+	if entry == nil {
+		if len(t.entries) >= t.maxStatements {
+			fingerprint = otherFingerprint
+			entry = t.entries[fingerprint]
+		}
+		if entry == nil {
+			entry = &statementEntry{stats: StatementStats{Fingerprint: fingerprint}}
+			t.entries[fingerprint] = entry
+		}
+	}
+
+	s := &entry.stats
+	s.Count++
+	if err != nil && err != ErrNoRows {
+		s.Errors++
+	}
+	s.TotalLatency += elapsed
+	if elapsed > s.MaxLatency {
+		s.MaxLatency = elapsed
+	}
+	s.RowsReturned += rowsReturned
+	s.RowsAffected += rowsAffected
+	entry.buckets[latencyBucket(elapsed)]++
+}
+
+func latencyBucket(d time.Duration) int {
+	if d <= latencyBucketBase {
+		return 0
+	}
+
+	i := int(math.Ceil(math.Log2(float64(d)/float64(latencyBucketBase)) * latencyBucketScale))
+	if i >= latencyBuckets {
+		return latencyBuckets - 1
+	}
+
+	return i
+}
+
+// 桶的上界
+func latencyBucketBound(i int) time.Duration {
+	return time.Duration(float64(latencyBucketBase) * math.Pow(2, float64(i)/latencyBucketScale))
+}
+
+func (e *statementEntry) snapshot() *StatementStats {
+	s := e.stats
+	rank := int64(math.Ceil(float64(s.Count) * 0.99))
+	var n int64
+	for i, c := range e.buckets {
+		n += int64(c)
+		if n >= rank {
+			s.P99Latency = latencyBucketBound(i)
+			break
+		}
+	}
+	if s.P99Latency > s.MaxLatency {
+		s.P99Latency = s.MaxLatency
+	}
+
+	return &s
+}
+
+// StatementStats 按总耗时从高到低排列，未启用WithStatementStats时返回nil
+func (db *DB) StatementStats() []*StatementStats {
+	t := db.statementStats
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	list := make([]*StatementStats, 0, len(t.entries))
+	for _, e := range t.entries {
+		list = append(list, e.snapshot())
+	}
+	t.mutex.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].TotalLatency > list[j].TotalLatency
+	})
+
+	return list
+}
+
+func (db *DB) ResetStatementStats() {
+	t := db.statementStats
+	if t == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.entries = map[string]*statementEntry{}
+}
+
+// DumpStatementStats 以JSON数组输出StatementStats
+func (db *DB) DumpStatementStats(w io.Writer) error {
+	list := db.StatementStats()
+	if list == nil {
+		list = []*StatementStats{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(list)
+}
@@ -0,0 +1,55 @@
+package wrap
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLatencyBucket(t *testing.T) {
+	if latencyBucket(0) != 0 || latencyBucket(latencyBucketBase) != 0 {
+		t.Fatal("durations below base should be in bucket 0")
+	}
+	if i := latencyBucket(2 * latencyBucketBase); i != latencyBucketScale {
+		t.Fatalf("bucket=%d", i)
+	}
+	if i := latencyBucket(time.Duration(math.MaxInt64)); i != latencyBuckets-1 {
+		t.Fatalf("bucket=%d", i)
+	}
+
+	// 桶上界不小于延迟，且误差不超过一个桶的宽度
+	ratio := math.Pow(2, 1.0/latencyBucketScale)
+	for d := 2 * latencyBucketBase; d < time.Hour; d = d*3/2 + 7 {
+		i := latencyBucket(d)
+		bound := latencyBucketBound(i)
+		if bound < d-1 || float64(bound) > float64(d)*ratio {
+			t.Fatalf("d=%s bucket=%d bound=%s", d, i, bound)
+		}
+		if latencyBucketBound(i-1) >= d {
+			t.Fatalf("d=%s bucket=%d lower=%s", d, i, latencyBucketBound(i-1))
+		}
+	}
+}
+
+func TestStatementP99(t *testing.T) {
+	table := &statementStatsTable{maxStatements: 10, entries: map[string]*statementEntry{}, fingerprints: map[string]string{}}
+	for i := 0; i < 99; i++ {
+		table.record("SELECT 1", time.Millisecond, nil, 1, 0)
+	}
+	table.record("SELECT 1", time.Second, nil, 1, 0)
+
+	s := table.entries["select ?"].snapshot()
+	if s.Count != 100 || s.MaxLatency != time.Second || s.RowsReturned != 100 {
+		t.Fatalf("stats=%+v", s)
+	}
+	if s.P99Latency < time.Millisecond || float64(s.P99Latency) > float64(time.Millisecond)*1.1 {
+		t.Fatalf("p99=%s", s.P99Latency)
+	}
+
+	// 只有一次时p99不超过最大值
+	table.record("UPDATE t SET a=1", 1500*time.Microsecond, nil, 0, 1)
+	s = table.entries["update t set a = ?"].snapshot()
+	if s.Count != 1 || s.P99Latency != s.MaxLatency {
+		t.Fatalf("stats=%+v", s)
+	}
+}
@@ -0,0 +1,206 @@
+package wrap
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+)
+
+// StmtCacheConfig 预编译语句缓存配置
+type StmtCacheConfig struct {
+	Size int // LRU容量，为0时为256
+	// 占位符超过此数量的语句不缓存，如较长的IN列表和批量写入，为0时为64
+	MaxPlaceholders int
+	Skip            func(query string) bool // 返回true的语句不缓存
+}
+
+type StmtCacheStats struct {
+	Size      int
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+// stmtCacheKey 语句在主库和各从库上分别预编译
+type stmtCacheKey struct {
+	pool  *sql.DB
+	query string
+}
+
+type stmtCacheEntry struct {
+	key     stmtCacheKey
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+	elem    *list.Element
+}
+
+type stmtCache struct {
+	config  StmtCacheConfig
+	mutex   sync.Mutex
+	entries map[stmtCacheKey]*stmtCacheEntry
+	lru     *list.List
+	stats   StmtCacheStats
+}
+
+// WithStmtCache Exec、Query、QueryRow按语句文本复用预编译语句，事务中只复用已缓存的语句并重新绑定到事务连接
+func WithStmtCache(config StmtCacheConfig) Option {
+	return func(db *DB) {
+		if config.Size <= 0 {
+			config.Size = 256
+		}
+		if config.MaxPlaceholders <= 0 {
+			config.MaxPlaceholders = 64
+		}
+		db.stmtCache = &stmtCache{config: config, entries: map[stmtCacheKey]*stmtCacheEntry{}, lru: list.New()}
+	}
+}
+
+type noStmtCacheContextKey struct{}
+
+// WithoutStmtCache ctx中执行的语句不使用预编译语句缓存
+func WithoutStmtCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noStmtCacheContextKey{}, true)
+}
+
+func (db *DB) StmtCacheStats() StmtCacheStats {
+	c := db.stmtCache
+	if c == nil {
+		return StmtCacheStats{}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	stats := c.stats
+	stats.Size = len(c.entries)
+	return stats
+}
+
+func (c *stmtCache) cacheable(ctx context.Context, query string) bool {
+	if ctx.Value(noStmtCacheContextKey{}) != nil {
+		return false
+	}
+
+	if strings.Count(query, "?") > c.config.MaxPlaceholders {
+		return false
+	}
+
+	return c.config.Skip == nil || !c.config.Skip(query)
+}
+
+// prepared 返回使用缓存语句执行的conn，release在语句执行后调用。
+// 只替换*sql.DB和*sql.Tx，显式Prepare的Stmt不变
+func (db *DB) prepared(ctx context.Context, c conn, query string) (conn, func()) {
+	cache := db.stmtCache
+	if cache == nil || !cache.cacheable(ctx, query) {
+		return c, func() {}
+	}
+
+	switch c := c.(type) {
+	case *sql.DB:
+		entry, err := db.acquireStmt(ctx, stmtCacheKey{pool: c, query: query})
+		if err != nil {
+			// 预编译失败时直接执行，错误由执行结果体现
+			return c, func() {}
+		}
+		return &stmtConn{stmt: entry.stmt, conn: c}, func() { cache.release(entry) }
+	case *sql.Tx:
+		// 未命中时直接执行，在连接池上预编译需要另取连接，连接数受限时会与事务互相等待
+		entry := cache.lookup(stmtCacheKey{pool: db.db, query: query})
+		if entry == nil {
+			return c, func() {}
+		}
+		// 事务结束时关闭
+		return &stmtConn{stmt: c.StmtContext(ctx, entry.stmt), conn: c}, func() { cache.release(entry) }
+	default:
+		return c, func() {}
+	}
+}
+
+// lookup 命中时增加引用计数
+func (c *stmtCache) lookup(key stmtCacheKey) *stmtCacheEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := c.entries[key]
+	if entry == nil {
+		c.stats.Misses++
+		return nil
+	}
+
+	c.stats.Hits++
+	entry.refs++
+	c.lru.MoveToFront(entry.elem)
+	return entry
+}
+
+func (db *DB) acquireStmt(ctx context.Context, key stmtCacheKey) (*stmtCacheEntry, error) {
+	c := db.stmtCache
+	if entry := c.lookup(key); entry != nil {
+		return entry, nil
+	}
+
+	call := &Call{Op: "Prepare", Name: "StmtCache.Prepare", Query: key.query}
+	err := db.invoke(ctx, call, func(ctx context.Context, call *Call) (err error) {
+		call.Stmt, err = key.pool.PrepareContext(ctx, call.Query)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// 并发预编译了同一语句
+	if entry := c.entries[key]; entry != nil {
+		call.Stmt.Close()
+		entry.refs++
+		c.lru.MoveToFront(entry.elem)
+		return entry, nil
+	}
+
+	entry := &stmtCacheEntry{key: key, stmt: call.Stmt, refs: 1}
+	entry.elem = c.lru.PushFront(entry)
+	c.entries[key] = entry
+
+	for c.lru.Len() > c.config.Size {
+		c.evict(c.lru.Back().Value.(*stmtCacheEntry))
+	}
+
+	return entry, nil
+}
+
+// evict 使用中的语句在release时关闭
+func (c *stmtCache) evict(entry *stmtCacheEntry) {
+	c.lru.Remove(entry.elem)
+	delete(c.entries, entry.key)
+	c.stats.Evictions++
+	entry.evicted = true
+	if entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+func (c *stmtCache) release(entry *stmtCacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+func (c *stmtCache) close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for c.lru.Len() > 0 {
+		entry := c.lru.Back().Value.(*stmtCacheEntry)
+		c.evict(entry)
+		c.stats.Evictions--
+	}
+}
@@ -0,0 +1,130 @@
+package wrap
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"testing"
+	"time"
+)
+
+func TestStmtCache(t *testing.T) {
+	db, mock := newMockDB(t, WithStmtCache(StmtCacheConfig{MaxPlaceholders: 2}))
+	ctx := context.Background()
+
+	mock.ExpectPrepare("UPDATE t SET a=? WHERE id=?")
+	mock.ExpectExec("UPDATE t SET a=? WHERE id=?").WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE t SET a=? WHERE id=?").WithArgs(2, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	// 占位符过多和WithoutStmtCache时不预编译
+	mock.ExpectExec("UPDATE t SET a=? WHERE id IN (?,?)").WithArgs(3, 1, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE t SET a=? WHERE id=?").WithArgs(4, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	for i := 1; i <= 2; i++ {
+		_, err := db.Exec(ctx, "UPDATE t SET a=? WHERE id=?", i, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := db.Exec(ctx, "UPDATE t SET a=? WHERE id IN (?,?)", 3, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(WithoutStmtCache(ctx), "UPDATE t SET a=? WHERE id=?", 4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := db.StmtCacheStats(); stats != (StmtCacheStats{Size: 1, Hits: 1, Misses: 1}) {
+		t.Fatalf("stats=%+v", stats)
+	}
+
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 淘汰使用中的语句时，在最后一次release后才关闭
+func TestStmtCacheEvictInUse(t *testing.T) {
+	db, mock := newMockDB(t, WithStmtCache(StmtCacheConfig{Size: 1}))
+	ctx := context.Background()
+
+	mock.ExpectPrepare("SELECT 1")
+	mock.ExpectPrepare("SELECT 2")
+	mock.ExpectExec("SELECT 1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	first, err := db.acquireStmt(ctx, stmtCacheKey{pool: db.db, query: "SELECT 1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := db.acquireStmt(ctx, stmtCacheKey{pool: db.db, query: "SELECT 2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.stmtCache.release(second)
+	if stats := db.StmtCacheStats(); stats != (StmtCacheStats{Size: 1, Misses: 2, Evictions: 1}) {
+		t.Fatalf("stats=%+v", stats)
+	}
+
+	if !first.evicted || first.refs != 1 {
+		t.Fatalf("evicted=%t refs=%d", first.evicted, first.refs)
+	}
+	_, err = first.stmt.ExecContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.stmtCache.release(first)
+	_, err = first.stmt.ExecContext(ctx)
+	if err == nil {
+		t.Fatal("statement not closed after release")
+	}
+
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 事务中命中时绑定到事务连接，未命中时直接执行，只有一个连接时也不会互相等待
+func TestStmtCacheInTx(t *testing.T) {
+	db, mock := newMockDB(t, WithStmtCache(StmtCacheConfig{}), WithMaxOpenConns(1))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	mock.ExpectPrepare("UPDATE t SET a=?")
+	mock.ExpectExec("UPDATE t SET a=?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := db.Exec(ctx, "UPDATE t SET a=?", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectBegin()
+	err = db.Transaction(ctx, nil, func(tx *Tx) error {
+		mock.ExpectExec("UPDATE t SET a=?").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+		_, err := tx.Exec(tx.Context(), "UPDATE t SET a=?", 2)
+		if err != nil {
+			return err
+		}
+
+		mock.ExpectExec("UPDATE t SET b=?").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+		_, err = tx.Exec(tx.Context(), "UPDATE t SET b=?", 3)
+		if err != nil {
+			return err
+		}
+
+		mock.ExpectCommit()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := db.StmtCacheStats(); stats != (StmtCacheStats{Size: 1, Hits: 1, Misses: 2}) {
+		t.Fatalf("stats=%+v", stats)
+	}
+
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,94 @@
+package wrap
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+const tracerName = "github.com/NeuronFramework/sql/wrap"
+
+// WithTracerProvider 为每次操作、事务及生成代码的DAO方法创建span，tp为nil时使用otel全局TracerProvider
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(db *DB) {
+		if tp == nil {
+			tp = otel.GetTracerProvider()
+		}
+		db.tracer = tp.Tracer(tracerName)
+		db.interceptors = append(db.interceptors, db.tracingInterceptor)
+	}
+}
+
+// Span 未启用追踪时为nil，方法可安全调用
+type Span struct {
+	span trace.Span
+}
+
+// StartSpan 生成代码在DAO方法开始时调用，name为Dao.Method，记录到ctx中用于指标。
+// 启用追踪时创建同名span，语句的span为其子span
+func (db *DB) StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	return db.startSpan(WithMethod(ctx, name), name)
+}
+
+func (db *DB) startSpan(ctx context.Context, name string) (context.Context, *Span) {
+	if db.tracer == nil {
+		return ctx, nil
+	}
+
+	attrs := []attribute.KeyValue{semconv.DBSystemMySQL}
+	if table := TableFromContext(ctx); table != "" {
+		attrs = append(attrs, semconv.DBSQLTable(table))
+	}
+	ctx, span := db.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx, &Span{span: span}
+}
+
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+
+	// 未查到记录不视为出错
+	if err != nil && !errors.Is(err, ErrNoRows) {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+func (db *DB) tracingInterceptor(ctx context.Context, call *Call, next Handler) error {
+	operation := strings.ToUpper(call.Op)
+	attrs := []attribute.KeyValue{semconv.DBSystemMySQL}
+	if call.Query != "" {
+		operation = queryOperation(call.Query)
+		attrs = append(attrs, semconv.DBStatement(SanitizeQuery(call.Query)))
+	}
+	attrs = append(attrs, semconv.DBOperation(operation))
+
+	name := operation
+	if table := TableFromContext(ctx); table != "" {
+		attrs = append(attrs, semconv.DBSQLTable(table))
+		name += " " + table
+	}
+
+	ctx, span := db.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	err := next(ctx, call)
+	(&Span{span: span}).End(err)
+	return err
+}
+
+// queryOperation 语句的第一个关键字
+func queryOperation(query string) string {
+	for _, t := range tokenize(query) {
+		if t.kind == tokenIdent {
+			return strings.ToUpper(t.text)
+		}
+	}
+
+	return ""
+}
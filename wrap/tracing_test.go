@@ -0,0 +1,166 @@
+package wrap
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func newTracingDB(t *testing.T) (*DB, sqlmock.Sqlmock, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() {
+		tp.Shutdown(context.Background())
+	})
+
+	db, mock := newMockDB(t, WithTracerProvider(tp))
+	return db, mock, exporter
+}
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]string {
+	attrs := map[attribute.Key]string{}
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	return attrs
+}
+
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+
+	t.Fatalf("span %s not found in %d spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+func TestTracingStatement(t *testing.T) {
+	db, mock, exporter := newTracingDB(t)
+	ctx := WithTable(context.Background(), "user")
+
+	mock.ExpectExec("UPDATE user SET name='bob' WHERE id=?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err := db.Exec(ctx, "UPDATE user SET name='bob' WHERE id=?", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("spans=%d", len(spans))
+	}
+	span := findSpan(t, spans, "UPDATE user")
+	attrs := spanAttributes(span)
+	expected := map[attribute.Key]string{
+		"db.system":    "mysql",
+		"db.statement": "UPDATE user SET name=? WHERE id=?",
+		"db.operation": "UPDATE",
+		"db.sql.table": "user",
+	}
+	for k, v := range expected {
+		if attrs[k] != v {
+			t.Fatalf("%s=%q, expected %q", k, attrs[k], v)
+		}
+	}
+	if span.Status.Code != codes.Unset {
+		t.Fatalf("status=%v", span.Status)
+	}
+}
+
+func TestTracingError(t *testing.T) {
+	db, mock, exporter := newTracingDB(t)
+
+	mock.ExpectQuery("SELECT id FROM user").WillReturnError(errors.New("query failed"))
+	_, err := db.Query(context.Background(), "SELECT id FROM user")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	span := findSpan(t, exporter.GetSpans(), "SELECT")
+	if span.Status.Code != codes.Error || span.Status.Description != "query failed" {
+		t.Fatalf("status=%v", span.Status)
+	}
+	if len(span.Events) != 1 || span.Events[0].Name != "exception" {
+		t.Fatalf("events=%v", span.Events)
+	}
+}
+
+func TestTracingTransaction(t *testing.T) {
+	db, mock, exporter := newTracingDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM user WHERE id=?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ctx, span := db.StartSpan(context.Background(), "UserDao.Delete")
+	err := db.Transaction(ctx, nil, func(tx *Tx) error {
+		_, err := tx.Exec(tx.Context(), "DELETE FROM user WHERE id=?", 1)
+		return err
+	})
+	span.End(err)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	dao := findSpan(t, spans, "UserDao.Delete")
+	tx := findSpan(t, spans, "Transaction")
+	if tx.Parent.SpanID() != dao.SpanContext.SpanID() {
+		t.Fatal("transaction span is not a child of the dao span")
+	}
+	for _, name := range []string{"BEGIN", "DELETE", "COMMIT"} {
+		s := findSpan(t, spans, name)
+		if s.Parent.SpanID() != tx.SpanContext.SpanID() {
+			t.Fatalf("%s span is not a child of the transaction span", name)
+		}
+		if s.SpanContext.TraceID() != dao.SpanContext.TraceID() {
+			t.Fatalf("%s span is in another trace", name)
+		}
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	failed := errors.New("failed")
+	err = db.Transaction(context.Background(), nil, func(tx *Tx) error {
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("err=%v", err)
+	}
+	spans = exporter.GetSpans()
+	tx = spans[len(spans)-1]
+	if tx.Name != "Transaction" || tx.Parent.IsValid() || tx.Status.Code != codes.Error {
+		t.Fatalf("span=%s status=%v", tx.Name, tx.Status)
+	}
+	if s := findSpan(t, spans[len(spans)-3:], "ROLLBACK"); s.Parent.SpanID() != tx.SpanContext.SpanID() {
+		t.Fatal("rollback span is not a child of the transaction span")
+	}
+}
+
+func TestSanitizeQuery(t *testing.T) {
+	tests := []struct {
+		query  string
+		result string
+	}{
+		{"SELECT * FROM t1 WHERE a='x' AND b=12 AND c=\"y\" AND `2d`=?", "SELECT * FROM t1 WHERE a=? AND b=? AND c=? AND `2d`=?"},
+		// 空的反引号标识符
+		{"SELECT `` FROM t WHERE a=1", "SELECT `` FROM t WHERE a=?"},
+	}
+
+	for _, test := range tests {
+		result := SanitizeQuery(test.query)
+		if result != test.result {
+			t.Errorf("SanitizeQuery(%q)=%s, expected %s", test.query, result, test.result)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package wrap
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AutoIncrement 批量插入时推算自增ID所需的服务端配置
+type AutoIncrement struct {
+	LockMode  int64 // innodb_autoinc_lock_mode，2(interleaved)时批量插入的ID不保证连续
+	Increment int64 // auto_increment_increment
+	Returning bool  // 支持INSERT ... RETURNING(MariaDB 10.5+)
+}
+
+func (db *DB) SetAutoIncrement(a *AutoIncrement) {
+	db.variablesMutex.Lock()
+	defer db.variablesMutex.Unlock()
+
+	db.autoIncrement = a
+}
+
+// AutoIncrement 首次调用时查询服务端配置并缓存。tx为nil时使用ctx中的事务，
+// 在事务中执行时使用事务的连接，避免再从连接池取得连接
+func (db *DB) AutoIncrement(ctx context.Context, tx *Tx) (*AutoIncrement, error) {
+	db.variablesMutex.Lock()
+	defer db.variablesMutex.Unlock()
+
+	if db.autoIncrement != nil {
+		return db.autoIncrement, nil
+	}
+
+	a := &AutoIncrement{}
+	var version string
+	ctx = WithPrimary(ctx)
+	err := db.Executor(ctx, tx).QueryRow(ctx, "SELECT @@innodb_autoinc_lock_mode,@@auto_increment_increment,VERSION()").
+		Scan(&a.LockMode, &a.Increment, &version)
+	if err != nil {
+		return nil, err
+	}
+	a.Returning = mariaDBSupportsReturning(version)
+	db.autoIncrement = a
+
+	return a, nil
+}
+
+var ErrIdsNotContiguous = ErrorWrap(fmt.Errorf("sql: auto increment ids are not contiguous (innodb_autoinc_lock_mode=2)"))
+
+// InsertIds 按首个自增ID推算批量插入的全部ID，无法保证连续时返回ErrIdsNotContiguous。
+// 此时行已写入，调用方不应视为写入失败，生成代码以Result.MarkIdsUnassigned标记
+func (db *DB) InsertIds(ctx context.Context, tx *Tx, result *Result, count int) ([]int64, error) {
+	a, err := db.AutoIncrement(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.LockMode == 2 {
+		return nil, ErrIdsNotContiguous
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, count)
+	for i := range ids {
+		ids[i] = id + int64(i)*a.Increment
+	}
+
+	return ids, nil
+}
+
+func mariaDBSupportsReturning(version string) bool {
+	if !strings.Contains(version, "MariaDB") {
+		return false
+	}
+
+	tokens := strings.SplitN(version, ".", 3)
+	if len(tokens) < 2 {
+		return false
+	}
+
+	major, err := strconv.Atoi(tokens[0])
+	if err != nil {
+		return false
+	}
+
+	minor, err := strconv.Atoi(tokens[1])
+	if err != nil {
+		return false
+	}
+
+	return major > 10 || (major == 10 && minor >= 5)
+}
+
+func (db *DB) SetMaxAllowedPacket(n int64) {
+	db.variablesMutex.Lock()
+	defer db.variablesMutex.Unlock()
+
+	db.maxAllowedPacket = n
+}
+
+// MaxAllowedPacket 首次调用时查询max_allowed_packet并缓存，tx的用法同AutoIncrement
+func (db *DB) MaxAllowedPacket(ctx context.Context, tx *Tx) (int64, error) {
+	db.variablesMutex.Lock()
+	defer db.variablesMutex.Unlock()
+
+	if db.maxAllowedPacket > 0 {
+		return db.maxAllowedPacket, nil
+	}
+
+	var n int64
+	ctx = WithPrimary(ctx)
+	err := db.Executor(ctx, tx).QueryRow(ctx, "SELECT @@max_allowed_packet").Scan(&n)
+	if err != nil {
+		return 0, err
+	}
+	db.maxAllowedPacket = n
+
+	return n, nil
+}
@@ -1,173 +1,450 @@
 package wrap
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
-	"github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"sync"
+	"time"
 )
 
-type Error struct {
-	Err error
-}
-
-var ErrNoRows = ErrorWrap(fmt.Errorf("sql: no rows in result set"))
-var ErrDuplicated = ErrorWrap(fmt.Errorf("sql: duplicated"))
-
-func (e *Error) Error() string {
-	return e.Err.Error()
-}
-
-func ErrorWrap(err error) *Error {
-	return &Error{Err: err}
-}
-
 type DB struct {
-	logger *zap.Logger
-	db     *sql.DB
+	logger       Logger
+	db           *sql.DB
+	now          func() time.Time
+	redactParams func(args []interface{}) []interface{}
+	logConfig    *LogConfig
+	queryTimeout time.Duration
+	interceptors []Interceptor
+	tracer       trace.Tracer
+	poolSettings []func(sqlDB *sql.DB)
+
+	statementStats *statementStatsTable
+	stmtCache      *stmtCache
+	replicas       *replicaSet
+	metrics        *metrics
+
+	variablesMutex   sync.Mutex
+	autoIncrement    *AutoIncrement
+	maxAllowedPacket int64
+
+	txStats txStats
 }
 
-func Open(driverName, dataSourceName string) (*DB, error) {
+func Open(driverName, dataSourceName string, opts ...Option) (*DB, error) {
 	db := &DB{}
-	db.logger = zap.L().Named("db")
+	db.logger = NewZapLogger(zap.L().Named("db"))
+	db.now = time.Now
+	db.redactParams = RedactParams
+	db.logConfig = DefaultLogConfig
+	for _, opt := range opts {
+		opt(db)
+	}
 
-	db.logger.Info("Open", zap.String("driverName", driverName), zap.String("dataSourceName", dataSourceName))
+	db.logger.Log(LogInfo, "Open", "driverName", driverName, "dataSourceName", RedactDSN(dataSourceName))
 	sqlDB, err := sql.Open(driverName, dataSourceName)
 	if err != nil {
-		db.logger.Error("Open", zap.Error(err))
+		db.logger.Log(LogError, "Open", "error", err)
 		return nil, ErrorWrap(err)
 	}
 	db.db = sqlDB
+	for _, f := range db.poolSettings {
+		f(sqlDB)
+	}
+
+	if db.replicas != nil {
+		err = db.openReplicas(driverName)
+		if err != nil {
+			sqlDB.Close()
+			return nil, err
+		}
+	}
 
-	return db, err
+	if db.metrics != nil {
+		err = db.metrics.register()
+		if err != nil {
+			db.logger.Log(LogError, "Open", "error", err)
+			db.Close()
+			return nil, err
+		}
+	}
+
+	return db, nil
+}
+
+var ErrConsistentSnapshotIsolation = ErrorWrap(fmt.Errorf("sql: consistent snapshot requires repeatable read"))
+
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+	// 以START TRANSACTION WITH CONSISTENT SNAPSHOT开启，仅可用于REPEATABLE READ
+	ConsistentSnapshot bool
+	// 大于0时整个事务超时后回滚，Tx.Context()返回带超时的ctx
+	Timeout time.Duration
+	Retry   *RetryPolicy // 为nil时不重试
 }
 
-func (db *DB) transaction(
-	ctx context.Context, readonly bool, f func(tx *Tx) (err error), isolation sql.IsolationLevel) (err error) {
-	db.logger.Info("Begin")
-	tx, err := db.db.BeginTx(ctx, &sql.TxOptions{Isolation: isolation, ReadOnly: readonly})
+// Transaction ctx中已有本DB的事务时以保存点嵌套执行，此时忽略opts
+func (db *DB) Transaction(ctx context.Context, opts *TxOptions, f func(tx *Tx) (err error)) (err error) {
+	if opts == nil {
+		opts = &TxOptions{}
+	}
+
+	// ctx中已有本DB的事务时使用保存点，避免另开连接
+	if parent := TxFromContext(ctx); parent != nil && parent.db == db {
+		return parent.Transaction(ctx, f)
+	}
+
+	if opts.ConsistentSnapshot &&
+		opts.Isolation != sql.LevelDefault && opts.Isolation != sql.LevelRepeatableRead {
+		return ErrConsistentSnapshotIsolation
+	}
+
+	if opts.Retry == nil {
+		return db.transaction(ctx, opts, f)
+	}
+
+	return db.retry(ctx, opts.Retry, func() error {
+		return db.transaction(ctx, opts, f)
+	})
+}
+
+func (db *DB) transaction(ctx context.Context, opts *TxOptions, f func(tx *Tx) (err error)) (err error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	ctx, span := db.startSpan(ctx, "Transaction")
+	defer func() {
+		span.End(err)
+	}()
+
+	begin := &Call{Op: "Begin", Name: "Begin", TxOptions: &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly}}
+	var tx txConn
+	err = db.invoke(ctx, begin, func(ctx context.Context, call *Call) (err error) {
+		if opts.ConsistentSnapshot {
+			tx, err = db.beginConsistentSnapshot(ctx, call.TxOptions.ReadOnly)
+			return err
+		}
+
+		call.Tx, err = db.db.BeginTx(ctx, call.TxOptions)
+		tx = call.Tx
+		return err
+	})
 	if err != nil {
-		db.logger.Error("DB.transaction", zap.Error(err))
 		return ErrorWrap(err)
 	}
 
+	t := &Tx{db: db, tx: tx}
+	t.ctx = NewTxContext(ctx, t)
+	committed := false
+	commitAttempted := false
+
+	// 回滚后执行OnRollback注册的函数，panic时继续抛出
 	defer func() {
-		db.logger.Info("Rollback")
-		rollbackErr := tx.Rollback()
-		if rollbackErr != nil {
-			db.logger.Error("Rollback", zap.Error(rollbackErr))
+		p := recover()
+		if p != nil {
+			db.logger.Log(LogError, "transaction panic", "panic", p)
+		}
+
+		if !committed {
+			// 提交失败时事务已结束
+			if !commitAttempted {
+				db.invoke(ctx, &Call{Op: "Rollback", Name: "Rollback", InTx: true, Tx: begin.Tx}, func(ctx context.Context, call *Call) error {
+					return tx.Rollback()
+				})
+			}
+
+			cause := error(err)
+			if p != nil {
+				cause = fmt.Errorf("panic: %v", p)
+			}
+			t.runRollbackHooks(cause)
+		}
+
+		if p != nil {
+			panic(p)
 		}
 	}()
 
-	err = f(&Tx{db: db, tx: tx})
+	err = f(t)
 	if err != nil {
-		db.logger.Info("transaction exec failed", zap.Error(err))
+		db.logger.Log(LogInfo, "transaction exec failed", "error", err)
 		return ErrorWrap(err)
 	}
 
-	db.logger.Info("Commit")
-	err = tx.Commit()
+	// 超时后database/sql已回滚事务，Commit只会返回sql.ErrTxDone
+	if ctx.Err() != nil {
+		return ErrorWrap(ctx.Err())
+	}
+
+	commitAttempted = true
+	err = db.invoke(ctx, &Call{Op: "Commit", Name: "Commit", InTx: true, Tx: begin.Tx}, func(ctx context.Context, call *Call) error {
+		return tx.Commit()
+	})
 	if err != nil {
-		db.logger.Error("Commit", zap.Error(err))
+		if ctx.Err() != nil {
+			return ErrorWrap(ctx.Err())
+		}
 		return ErrorWrap(err)
 	}
+	committed = true
+	db.markWrite(ctx)
+	t.runCommitHooks()
 
 	return nil
 }
 
+// txConn 为*sql.Tx和*snapshotTx的公共方法
+type txConn interface {
+	conn
+	Commit() error
+	Rollback() error
+}
+
+// snapshotTx 在专用连接上以START TRANSACTION WITH CONSISTENT SNAPSHOT开启的事务，
+// database/sql的BeginTx无法指定该修饰，而在已开启的事务中再次START TRANSACTION会隐式提交
+type snapshotTx struct {
+	*sql.Conn
+	ctx context.Context
+}
+
+func (db *DB) beginConsistentSnapshot(ctx context.Context, readonly bool) (*snapshotTx, error) {
+	c, err := db.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx := &snapshotTx{Conn: c, ctx: ctx}
+
+	// 只作用于下一个事务
+	_, err = c.ExecContext(ctx, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ")
+	if err != nil {
+		tx.discard()
+		return nil, err
+	}
+
+	query := "START TRANSACTION WITH CONSISTENT SNAPSHOT"
+	if readonly {
+		query += ", READ ONLY"
+	}
+	_, err = c.ExecContext(ctx, query)
+	if err != nil {
+		tx.discard()
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// Commit 与*sql.Tx相同，ctx结束后不再提交而是回滚
+func (tx *snapshotTx) Commit() error {
+	if err := tx.ctx.Err(); err != nil {
+		tx.end("ROLLBACK")
+		return err
+	}
+
+	return tx.end("COMMIT")
+}
+
+func (tx *snapshotTx) Rollback() error {
+	return tx.end("ROLLBACK")
+}
+
+// end ctx可能已结束，不能因此跳过COMMIT、ROLLBACK而把未结束的事务归还连接池
+func (tx *snapshotTx) end(query string) error {
+	_, err := tx.ExecContext(context.Background(), query)
+	if err != nil {
+		tx.discard()
+		return err
+	}
+
+	return tx.Close()
+}
+
+// discard 关闭连接而不归还连接池
+func (tx *snapshotTx) discard() {
+	tx.Raw(func(driverConn interface{}) error {
+		return driver.ErrBadConn
+	})
+}
+
+func (db *DB) TransactionReadUncommitted(ctx context.Context, readonly bool, f func(tx *Tx) (err error)) (err error) {
+	return db.Transaction(ctx, &TxOptions{Isolation: sql.LevelReadUncommitted, ReadOnly: readonly}, f)
+}
+
 func (db *DB) TransactionReadCommitted(ctx context.Context, readonly bool, f func(tx *Tx) (err error)) (err error) {
-	return db.transaction(ctx, readonly, f, sql.LevelReadCommitted)
+	return db.Transaction(ctx, &TxOptions{Isolation: sql.LevelReadCommitted, ReadOnly: readonly}, f)
 }
 
 func (db *DB) TransactionRepeatableRead(ctx context.Context, readonly bool, f func(tx *Tx) (err error)) (err error) {
-	return db.transaction(ctx, readonly, f, sql.LevelRepeatableRead)
+	return db.Transaction(ctx, &TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: readonly}, f)
+}
+
+func (db *DB) TransactionSerializable(ctx context.Context, readonly bool, f func(tx *Tx) (err error)) (err error) {
+	return db.Transaction(ctx, &TxOptions{Isolation: sql.LevelSerializable, ReadOnly: readonly}, f)
 }
 
 func (db *DB) Prepare(ctx context.Context, query string) (*Stmt, error) {
-	db.logger.Info("DB.Prepare", zap.Any("ctx", ctx.Err()), zap.String("query", query))
-	stmt, err := db.db.PrepareContext(ctx, query)
+	call := &Call{Op: "Prepare", Name: "DB.Prepare", Query: query}
+	err := db.invoke(ctx, call, func(ctx context.Context, call *Call) (err error) {
+		call.Stmt, err = db.db.PrepareContext(ctx, call.Query)
+		return err
+	})
 	if err != nil {
-		db.logger.Error("DB.Prepare", zap.Error(err))
 		return nil, ErrorWrap(err)
 	}
 
-	return &Stmt{db: db, stmt: stmt, query: query}, nil
+	return &Stmt{db: db, stmt: call.Stmt, query: call.Query}, nil
 }
 
-func (db *DB) Query(ctx context.Context, tx *Tx, query string, args ...interface{}) (*Rows, error) {
-	db.logger.Info("DB.Query", zap.Any("ctx", ctx.Err()), zap.String("query", fmt.Sprintf(query, args...)))
+// Executor 由*DB和*Tx实现，生成代码通过DB.Executor(ctx,tx)取得
+type Executor interface {
+	Query(ctx context.Context, query string, args ...interface{}) (*Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) *Row
+	Exec(ctx context.Context, query string, args ...interface{}) (*Result, error)
+}
 
-	var rows *sql.Rows
-	var err error
+// conn 为*sql.DB和*sql.Tx的公共方法
+type conn interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
 
+// Executor tx为nil时使用ctx中绑定的事务，事务不属于db时返回的Executor所有操作均失败
+func (db *DB) Executor(ctx context.Context, tx *Tx) Executor {
 	if tx == nil {
-		rows, err = db.db.QueryContext(ctx, query, args...)
-	} else {
-		rows, err = tx.tx.QueryContext(ctx, query, args)
+		tx = TxFromContext(ctx)
 	}
 
-	if err != nil {
-		db.logger.Error("DB.Query", zap.Error(err))
-		return nil, ErrorWrap(err)
+	if tx == nil {
+		return db
 	}
 
-	return &Rows{db: db, rows: rows}, nil
+	if tx.db != db {
+		db.logger.Log(LogError, "DB.Executor", "error", ErrTxMismatch)
+		return &errExecutor{err: ErrTxMismatch}
+	}
+
+	return tx
 }
 
-func (db *DB) QueryRow(ctx context.Context, tx *Tx, query string, args ...interface{}) *Row {
-	db.logger.Info("DB.QueryRow", zap.Any("ctx", ctx.Err()), zap.String("query", fmt.Sprintf(query, args...)))
+// Query 不再接收tx参数，事务内使用Tx.Query或DB.Executor(ctx,tx).Query，QueryRow和Exec同
+func (db *DB) Query(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	return db.query(ctx, db.reader(ctx, query), "DB.Query", query, args...)
+}
 
-	var row *sql.Row
-	if tx == nil {
-		row = db.db.QueryRowContext(ctx, query, args...)
-	} else {
-		row = tx.tx.QueryRowContext(ctx, query, args...)
-	}
+func (db *DB) QueryRow(ctx context.Context, query string, args ...interface{}) *Row {
+	return db.queryRow(ctx, db.reader(ctx, query), "DB.QueryRow", query, args...)
+}
 
-	return &Row{db: db, row: row}
+func (db *DB) Exec(ctx context.Context, query string, args ...interface{}) (*Result, error) {
+	return db.exec(ctx, db.db, "DB.Exec", query, args...)
 }
 
-func (db *DB) Exec(ctx context.Context, tx *Tx, query string, args ...interface{}) (*Result, error) {
-	db.logger.Info("DB.Exec", zap.Any("ctx", ctx.Err()), zap.String("query", fmt.Sprintf(query, args...)))
+func inTx(c conn) bool {
+	_, ok := c.(txConn)
+	return ok
+}
+
+func (db *DB) query(ctx context.Context, c conn, name string, query string, args ...interface{}) (*Rows, error) {
+	ctx, cancel := db.queryContext(ctx)
+	info := newQueryInfo(ctx, query, args, inTx(c))
+	call := &Call{Op: "Query", Name: name, Query: query, Args: args, InTx: info.inTx, conn: c}
+	err := db.invoke(ctx, call, func(ctx context.Context, call *Call) (err error) {
+		c, release := db.prepared(ctx, c, call.Query)
+		defer release()
+		call.Rows, err = c.QueryContext(ctx, call.Query, call.Args...)
+		return err
+	})
+	info.query, info.args = call.Query, call.Args
+	if err != nil {
+		cancel()
+		call.runAfterClose()
+		return nil, db.wrapError(err, "Query", info)
+	}
 
-	var result sql.Result
-	var err error
+	return &Rows{db: db, rows: call.Rows, info: info, cancel: cancel, call: call}, nil
+}
 
-	if tx == nil {
-		result, err = db.db.ExecContext(ctx, query, args...)
-	} else {
-		result, err = tx.tx.ExecContext(ctx, query, args...)
+func (db *DB) queryRow(ctx context.Context, c conn, name string, query string, args ...interface{}) *Row {
+	ctx, cancel := db.queryContext(ctx)
+	info := newQueryInfo(ctx, query, args, inTx(c))
+	call := &Call{Op: "QueryRow", Name: name, Query: query, Args: args, InTx: info.inTx, conn: c}
+	err := db.invoke(ctx, call, func(ctx context.Context, call *Call) error {
+		c, release := db.prepared(ctx, c, call.Query)
+		defer release()
+		call.Row = c.QueryRowContext(ctx, call.Query, call.Args...)
+		return call.Row.Err()
+	})
+	info.query, info.args = call.Query, call.Args
+	if err != nil {
+		cancel()
+		call.runAfterClose()
+		return &Row{db: db, err: db.wrapError(err, "QueryRow", info)}
 	}
 
+	return &Row{db: db, row: call.Row, info: info, cancel: cancel, call: call}
+}
+
+func (db *DB) exec(ctx context.Context, c conn, name string, query string, args ...interface{}) (*Result, error) {
+	ctx, cancel := db.queryContext(ctx)
+	defer cancel()
+	info := newQueryInfo(ctx, query, args, inTx(c))
+	call := &Call{Op: "Exec", Name: name, Query: query, Args: args, InTx: info.inTx, conn: c}
+	err := db.invoke(ctx, call, func(ctx context.Context, call *Call) (err error) {
+		c, release := db.prepared(ctx, c, call.Query)
+		defer release()
+		call.Result, err = c.ExecContext(ctx, call.Query, call.Args...)
+		return err
+	})
+	call.runAfterClose()
+	info.query, info.args = call.Query, call.Args
 	if err != nil {
-		db.logger.Error("DB.Exec", zap.Error(err))
-		switch err.(type) {
-		case *mysql.MySQLError:
-			mysqlErr := err.(*mysql.MySQLError)
-			if mysqlErr.Number == 1062 {
-				return nil, ErrDuplicated
-			}
-			return nil, ErrorWrap(mysqlErr)
-		default:
-			return nil, ErrorWrap(err)
-		}
+		return nil, db.wrapError(err, "Exec", info)
+	}
+	if !info.inTx {
+		db.markWrite(ctx)
 	}
 
-	return &Result{db: db, result: result}, err
+	return &Result{db: db, result: call.Result, info: info}, nil
+}
+
+// SetClock 替换生成代码写入create_time/update_time所用的时钟，便于测试
+func (db *DB) SetClock(now func() time.Time) {
+	db.now = now
+}
+
+func (db *DB) Now() time.Time {
+	return db.now()
 }
 
 func (db *DB) Close() error {
-	db.logger.Info("DB.Close")
+	db.logger.Log(LogInfo, "DB.Close")
+	if db.stmtCache != nil {
+		db.stmtCache.close()
+	}
+	if db.replicas != nil {
+		db.replicas.close()
+	}
 	return db.db.Close()
 }
 
+// Stats 连接池状态
+func (db *DB) Stats() sql.DBStats {
+	return db.db.Stats()
+}
+
 func (db *DB) Ping(ctx context.Context) error {
-	db.logger.Info("DB.Ping")
+	db.logger.Log(LogInfo, "DB.Ping")
 	err := db.db.PingContext(ctx)
 	if err != nil {
-		db.logger.Error("DB.Ping", zap.Error(err))
+		db.logger.Log(LogError, "DB.Ping", "error", err)
 		return ErrorWrap(err)
 	}
 
@@ -175,8 +452,33 @@ func (db *DB) Ping(ctx context.Context) error {
 }
 
 type Tx struct {
-	db *DB
-	tx *sql.Tx
+	db  *DB
+	tx  txConn
+	ctx context.Context
+
+	parent      *Tx
+	savepoint   string
+	savepointId int
+
+	commitHooks   []func()
+	rollbackHooks []func(err error)
+}
+
+// Context 返回绑定了本事务的ctx
+func (tx *Tx) Context() context.Context {
+	return tx.ctx
+}
+
+func (tx *Tx) Query(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	return tx.db.query(ctx, tx.tx, "Tx.Query", query, args...)
+}
+
+func (tx *Tx) QueryRow(ctx context.Context, query string, args ...interface{}) *Row {
+	return tx.db.queryRow(ctx, tx.tx, "Tx.QueryRow", query, args...)
+}
+
+func (tx *Tx) Exec(ctx context.Context, query string, args ...interface{}) (*Result, error) {
+	return tx.db.exec(ctx, tx.tx, "Tx.Exec", query, args...)
 }
 
 type Stmt struct {
@@ -188,7 +490,7 @@ type Stmt struct {
 func (s *Stmt) Close() error {
 	err := s.stmt.Close()
 	if err != nil {
-		s.db.logger.Error("Stmt.Close", zap.Error(err))
+		s.db.logger.Log(LogError, "Stmt.Close", "error", err)
 		return ErrorWrap(err)
 	}
 
@@ -196,64 +498,79 @@ func (s *Stmt) Close() error {
 }
 
 func (s *Stmt) Exec(ctx context.Context, args ...interface{}) (*Result, error) {
-	buf := bytes.NewBufferString("")
-	for _, v := range args {
-		buf.WriteString(fmt.Sprint(v) + " ")
-	}
+	return s.db.exec(ctx, &stmtConn{stmt: s.stmt, conn: s.db.db}, "Stmt.Exec", s.query, args...)
+}
 
-	s.db.logger.Info("Stmt.Exec", zap.Any("ctx", ctx.Err()), zap.String("stmt", s.query), zap.String("query", buf.String()))
-	result, err := s.stmt.ExecContext(ctx, args...)
-	if err != nil {
-		s.db.logger.Error("Stmt.Exec", zap.Error(err))
-		return nil, ErrorWrap(err)
-	}
+func (s *Stmt) Query(ctx context.Context, args ...interface{}) (*Rows, error) {
+	return s.db.query(ctx, &stmtConn{stmt: s.stmt, conn: s.db.db}, "Stmt.Query", s.query, args...)
+}
 
-	return &Result{db: s.db, result: result}, nil
+func (s *Stmt) QueryRow(ctx context.Context, args ...interface{}) *Row {
+	return s.db.queryRow(ctx, &stmtConn{stmt: s.stmt, conn: s.db.db}, "Stmt.QueryRow", s.query, args...)
 }
 
-func (s *Stmt) Query(ctx context.Context, args ...interface{}) (*Rows, error) {
-	buf := bytes.NewBufferString("")
-	for _, v := range args {
-		buf.WriteString(fmt.Sprint(v) + ",")
-	}
+// stmtConn 使Stmt与DB、Tx共用执行逻辑，query参数仅用于日志
+type stmtConn struct {
+	stmt *sql.Stmt
+	conn conn // 语句所属的连接池或事务
+}
 
-	s.db.logger.Info("Stmt.Query", zap.Any("ctx", ctx.Err()), zap.String("stmt", s.query), zap.String("query", buf.String()))
-	rows, err := s.stmt.QueryContext(ctx, args...)
-	if err != nil {
-		s.db.logger.Error("Stmt.Query", zap.Error(err))
-		return nil, ErrorWrap(err)
-	}
-	return &Rows{db: s.db, rows: rows}, nil
+func (c *stmtConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.stmt.QueryContext(ctx, args...)
 }
 
-func (s *Stmt) QueryRow(ctx context.Context, args ...interface{}) *Row {
-	buf := bytes.NewBufferString("")
-	for _, v := range args {
-		buf.WriteString(fmt.Sprint(v) + ",")
-	}
+func (c *stmtConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.stmt.QueryRowContext(ctx, args...)
+}
 
-	s.db.logger.Info("Stmt.QueryRow", zap.Any("ctx", ctx.Err()), zap.String("stmt", s.query), zap.String("query", buf.String()))
-	row := s.stmt.QueryRowContext(ctx, args...)
-	return &Row{db: s.db, row: row}
+func (c *stmtConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.stmt.ExecContext(ctx, args...)
 }
 
 type Rows struct {
-	db   *DB
-	rows *sql.Rows
+	db     *DB
+	rows   *sql.Rows
+	info   *queryInfo
+	cancel context.CancelFunc
+	call   *Call
+}
+
+// release 结果集关闭后释放超时ctx并执行Call.AfterClose
+func (r *Rows) release() {
+	r.cancel()
+	r.call.runAfterClose()
 }
 
 func (r *Rows) Err() error {
 	err := r.rows.Err()
 	if err != nil {
-		r.db.logger.Error("Rows.Err", zap.Error(err))
-		return ErrorWrap(err)
+		r.db.logger.Log(LogError, "Rows.Err", "error", err)
+		return r.db.wrapError(err, "Query", r.info)
+	}
+
+	return nil
+}
+
+func (r *Rows) Close() error {
+	defer r.release()
+
+	err := r.rows.Close()
+	if err != nil {
+		r.db.logger.Log(LogError, "Rows.Close", "error", err)
+		return r.db.wrapError(err, "Query", r.info)
 	}
 
 	return nil
 }
 
 func (r *Rows) Next() bool {
-	return r.rows.Next()
+	if !r.rows.Next() {
+		r.release()
+		return false
+	}
+	r.call.RowsReturned++
+
+	return true
 }
 
 func (r *Rows) Scan(dest ...interface{}) error {
@@ -263,28 +580,50 @@ func (r *Rows) Scan(dest ...interface{}) error {
 			return ErrNoRows
 		}
 
-		r.db.logger.Error("Rows.Scan", zap.Error(err))
-		return ErrorWrap(err)
+		r.db.logger.Log(LogError, "Rows.Scan", "error", err)
+		return r.db.wrapError(err, "Scan", r.info)
 	}
 
 	return nil
 }
 
 type Row struct {
-	db  *DB
-	row *sql.Row
+	db     *DB
+	row    *sql.Row
+	err    error
+	info   *queryInfo
+	cancel context.CancelFunc
+	call   *Call
+}
+
+// Err 返回执行语句时的错误，未查到记录的错误在Scan时返回
+func (r *Row) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return r.row.Err()
 }
 
 func (r *Row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer func() {
+		r.cancel()
+		r.call.runAfterClose()
+	}()
+
 	err := r.row.Scan(dest...)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return ErrNoRows
 		}
 
-		r.db.logger.Error("Row.Scan", zap.Error(err))
-		return ErrorWrap(err)
+		r.db.logger.Log(LogError, "Row.Scan", "error", err)
+		return r.db.wrapError(err, "Scan", r.info)
 	}
+	r.call.RowsReturned = 1
 
 	return nil
 }
@@ -292,22 +631,59 @@ func (r *Row) Scan(dest ...interface{}) error {
 type Result struct {
 	db     *DB
 	result sql.Result
+	info   *queryInfo
+
+	idsUnassigned bool
+}
+
+type staticResult struct {
+	lastInsertId int64
+	rowsAffected int64
+}
+
+func (r *staticResult) LastInsertId() (int64, error) {
+	return r.lastInsertId, nil
+}
+
+func (r *staticResult) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+// NewErrorRow Scan时返回err，用于执行前即出错的查询
+func NewErrorRow(err error) *Row {
+	return &Row{err: err}
+}
+
+// NewResult 用于无法通过Exec取得结果的写入，如INSERT ... RETURNING
+func NewResult(lastInsertId int64, rowsAffected int64) *Result {
+	return &Result{result: &staticResult{lastInsertId: lastInsertId, rowsAffected: rowsAffected}}
 }
 
 func (r *Result) LastInsertId() (int64, error) {
 	n, err := r.result.LastInsertId()
 	if err != nil {
-		r.db.logger.Error("Result.LastInsertId", zap.Error(err))
-		return 0, ErrorWrap(err)
+		r.db.logger.Log(LogError, "Result.LastInsertId", "error", err)
+		return 0, r.db.wrapError(err, "Exec", r.info)
 	}
 
 	return n, nil
 }
+
+// IdsAssigned 批量插入是否已将自增ID写回实体。自增ID不连续时行已写入但未写回ID，此时为false
+func (r *Result) IdsAssigned() bool {
+	return !r.idsUnassigned
+}
+
+// MarkIdsUnassigned 生成代码在无法写回自增ID时调用，写入本身仍然成功
+func (r *Result) MarkIdsUnassigned() {
+	r.idsUnassigned = true
+}
+
 func (r *Result) RowsAffected() (int64, error) {
 	n, err := r.result.RowsAffected()
 	if err != nil {
-		r.db.logger.Error("Result.RowsAffected", zap.Error(err))
-		return 0, ErrorWrap(err)
+		r.db.logger.Log(LogError, "Result.RowsAffected", "error", err)
+		return 0, r.db.wrapError(err, "Exec", r.info)
 	}
 
 	return n, nil
@@ -0,0 +1,289 @@
+package wrap
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var mockDSNId atomic.Int64
+
+// newMockDSN 每次使用新的DSN，sqlmock不允许重复注册
+func newMockDSN(t *testing.T) (string, sqlmock.Sqlmock) {
+	t.Helper()
+
+	dsn := fmt.Sprintf("sqlmock_%d", mockDSNId.Add(1))
+	_, mock, err := sqlmock.NewWithDSN(dsn, sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dsn, mock
+}
+
+func newMockDB(t *testing.T, opts ...Option) (*DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	dsn, mock := newMockDSN(t)
+	db, err := Open("sqlmock", dsn, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db, mock
+}
+
+// runExecutors 分别直接在DB上和在事务中执行f，两者行为应一致
+var runExecutors = []struct {
+	name string
+	run  func(t *testing.T, db *DB, mock sqlmock.Sqlmock, f func(e Executor))
+}{
+	{"DB", func(t *testing.T, db *DB, mock sqlmock.Sqlmock, f func(e Executor)) {
+		f(db.Executor(context.Background(), nil))
+	}},
+	{"Tx", func(t *testing.T, db *DB, mock sqlmock.Sqlmock, f func(e Executor)) {
+		mock.ExpectBegin()
+		err := db.Transaction(context.Background(), nil, func(tx *Tx) error {
+			f(tx)
+			mock.ExpectCommit()
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}},
+	{"TxContext", func(t *testing.T, db *DB, mock sqlmock.Sqlmock, f func(e Executor)) {
+		mock.ExpectBegin()
+		err := db.Transaction(context.Background(), nil, func(tx *Tx) error {
+			f(db.Executor(tx.Context(), nil))
+			mock.ExpectCommit()
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}},
+}
+
+func TestExecutorQuery(t *testing.T) {
+	for _, r := range runExecutors {
+		t.Run(r.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			r.run(t, db, mock, func(e Executor) {
+				mock.ExpectQuery("SELECT id,name FROM t WHERE id>?").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "a").AddRow(3, "b"))
+
+				rows, err := e.Query(context.Background(), "SELECT id,name FROM t WHERE id>?", 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer rows.Close()
+
+				var names []string
+				for rows.Next() {
+					var id int64
+					var name string
+					err = rows.Scan(&id, &name)
+					if err != nil {
+						t.Fatal(err)
+					}
+					names = append(names, name)
+				}
+				if rows.Err() != nil {
+					t.Fatal(rows.Err())
+				}
+				if strings.Join(names, ",") != "a,b" {
+					t.Fatalf("names=%v", names)
+				}
+			})
+
+			err := mock.ExpectationsWereMet()
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestExecutorQueryRow(t *testing.T) {
+	for _, r := range runExecutors {
+		t.Run(r.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			r.run(t, db, mock, func(e Executor) {
+				mock.ExpectQuery("SELECT name FROM t WHERE id=?").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("a"))
+				mock.ExpectQuery("SELECT name FROM t WHERE id=?").WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+				var name string
+				err := e.QueryRow(context.Background(), "SELECT name FROM t WHERE id=?", 1).Scan(&name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if name != "a" {
+					t.Fatalf("name=%s", name)
+				}
+
+				err = e.QueryRow(context.Background(), "SELECT name FROM t WHERE id=?", 2).Scan(&name)
+				if err != ErrNoRows {
+					t.Fatalf("err=%v", err)
+				}
+			})
+
+			err := mock.ExpectationsWereMet()
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestExecutorExec(t *testing.T) {
+	for _, r := range runExecutors {
+		t.Run(r.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			r.run(t, db, mock, func(e Executor) {
+				mock.ExpectExec("UPDATE t SET name=? WHERE id=?").WithArgs("a", 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO t(name) VALUES (?)").WithArgs("b").
+					WillReturnError(errors.New("exec failed"))
+
+				result, err := e.Exec(context.Background(), "UPDATE t SET name=? WHERE id=?", "a", 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				n, err := result.RowsAffected()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n != 1 {
+					t.Fatalf("rowsAffected=%d", n)
+				}
+
+				_, err = e.Exec(context.Background(), "INSERT INTO t(name) VALUES (?)", "b")
+				var wrapErr *Error
+				if !errors.As(err, &wrapErr) {
+					t.Fatalf("err=%v", err)
+				}
+				if wrapErr.Op != "Exec" || wrapErr.Query != "INSERT INTO t(name) VALUES (?)" {
+					t.Fatalf("err=%+v", wrapErr)
+				}
+				if wrapErr.InTx != (r.name != "DB") {
+					t.Fatalf("inTx=%t", wrapErr.InTx)
+				}
+			})
+
+			err := mock.ExpectationsWereMet()
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestExecutorTxMismatch(t *testing.T) {
+	db, mock := newMockDB(t)
+	other, _ := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	err := db.Transaction(context.Background(), nil, func(tx *Tx) error {
+		_, err := other.Executor(context.Background(), tx).Exec(context.Background(), "DELETE FROM t")
+		if err != ErrTxMismatch {
+			t.Fatalf("err=%v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 不经过BeginTx，在同一连接上开启事务，语句、提交和回滚都在该连接上执行
+func TestConsistentSnapshot(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectExec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT COUNT(*) FROM t").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectExec("COMMIT").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	opts := &TxOptions{ConsistentSnapshot: true, ReadOnly: true}
+	err := db.Transaction(context.Background(), opts, func(tx *Tx) error {
+		var count int64
+		err := db.Executor(tx.Context(), nil).QueryRow(tx.Context(), "SELECT COUNT(*) FROM t").Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count != 3 {
+			t.Fatalf("count=%d", count)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("START TRANSACTION WITH CONSISTENT SNAPSHOT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE t SET a=1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("ROLLBACK").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	failed := errors.New("failed")
+	err = db.Transaction(context.Background(), &TxOptions{ConsistentSnapshot: true}, func(tx *Tx) error {
+		_, err := tx.Exec(tx.Context(), "UPDATE t SET a=1")
+		if err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("err=%v", err)
+	}
+
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Transaction(context.Background(), &TxOptions{ConsistentSnapshot: true, Isolation: sql.LevelReadCommitted},
+		func(tx *Tx) error {
+			return nil
+		})
+	if err != ErrConsistentSnapshotIsolation {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestTransactionTimeout(t *testing.T) {
+	db, mock := newMockDB(t)
+	var rollbackErr error
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err := db.Transaction(context.Background(), &TxOptions{Timeout: 10 * time.Millisecond}, func(tx *Tx) error {
+		tx.OnRollback(func(err error) { rollbackErr = err })
+		<-tx.Context().Done()
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || ErrorClass(err) != "timeout" {
+		t.Fatalf("err=%v", err)
+	}
+	if !errors.Is(rollbackErr, context.DeadlineExceeded) {
+		t.Fatalf("rollbackErr=%v", rollbackErr)
+	}
+
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}